@@ -1,17 +1,25 @@
 package gocloudurls
 
 import (
-	"fmt"
 	"net/url"
-	"os"
+	"regexp"
 	"strings"
 )
 
-// NormalizeBlobURL normalize blob URL. environ assumes os.Environ().
+// NormalizeBlobURL normalize blob URL. environ has the form of os.Environ().
 //
 // When region is not specified for S3, this function gets region information from AWS_REGION
 // environment variable.
 //
+// When storage account is not specified for Azure Blob Storage, this function gets it from
+// AZURE_STORAGE_ACCOUNT environment variable.
+//
+// Native URLs and ARNs that are copied from cloud consoles (S3, Cloud Storage and Azure Blob Storage) are
+// also accepted. If the host name has region, it is used instead of AWS_REGION.
+//
+// If AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL environment variable is set (for MinIO, LocalStack and so on),
+// ``endpoint``, ``disableSSL`` and ``s3ForcePathStyle`` query parameters are added to S3 URL.
+//
 // If "mem" is specified, it returns "memblob" URL.
 // It other names specified, it returns fileblob URL.
 //
@@ -20,20 +28,61 @@ import (
 //   * ``mem`` → ``mem://``
 //   * ``folder`` → ``file://folder``
 //   * ``s3://my-bucket`` → ``s3://my-bucket?region=us-west-1``
-func NormalizeBlobURL(srcUrl string, environ []string) (string, error) {
-	return normalizeBlobURL(srcUrl, os.Environ())
+//   * ``azblob://my-container`` → ``azblob://my-container?storage_account=myaccount``
+//   * ``https://myaccount.blob.core.windows.net/my-container`` → ``azblob://my-container?storage_account=myaccount``
+//   * ``arn:aws:s3:::my-bucket`` → ``s3://my-bucket?region=us-west-1``
+//   * ``https://my-bucket.s3.us-west-2.amazonaws.com`` → ``s3://my-bucket?region=us-west-2``
+//   * ``https://s3.us-west-2.amazonaws.com/my-bucket`` → ``s3://my-bucket?region=us-west-2``
+//   * ``https://storage.googleapis.com/my-bucket`` → ``gs://my-bucket``
+//
+// Sub directory after bucket name is moved to ``prefix`` query parameter:
+//
+//   * ``s3://my-bucket/tenant-a/uploads`` → ``s3://my-bucket?prefix=tenant-a%2Fuploads%2F&region=us-west-1``
+//   * ``folder/sub`` → ``file://folder?prefix=sub%2F``
+func NormalizeBlobURL(srcUrl string, environ []string, opt ...BlobOption) (string, error) {
+	var o BlobOption
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	return normalizeBlobURLWithOption(srcUrl, EnvList(environ), o)
+}
+
+// BlobOption is a option for NormalizeBlobURL
+//
+// Prefix is added to the prefix of configured bucket. It is good for applications that
+// separates blob keys by tenants.
+type BlobOption struct {
+	Prefix string
 }
 
 // MustNormalizeBlobURL is similar to NormalizeBlobURL but raise panic if there is error
-func MustNormalizeBlobURL(srcUrl string, environ []string) string {
-	result, err := NormalizeBlobURL(srcUrl, environ)
+func MustNormalizeBlobURL(srcUrl string, environ []string, opt ...BlobOption) string {
+	result, err := NormalizeBlobURL(srcUrl, environ, opt...)
 	if err != nil {
 		panic(err)
 	}
 	return result
 }
 
-func normalizeBlobURL(srcUrl string, environ []string) (string, error) {
+func normalizeBlobURLWithOption(srcUrl string, env EnvSource, o BlobOption) (string, error) {
+	result, err := normalizeBlobURL(srcUrl, env)
+	if err != nil {
+		return "", withInput(err, srcUrl)
+	}
+	if o.Prefix == "" {
+		return result, nil
+	}
+	u, err := url.Parse(result)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("prefix", joinBlobPrefix(q.Get("prefix"), o.Prefix))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func normalizeBlobURL(srcUrl string, env EnvSource) (string, error) {
 	u, err := url.Parse(srcUrl)
 	if err != nil {
 		return "", err
@@ -43,27 +92,216 @@ func normalizeBlobURL(srcUrl string, environ []string) (string, error) {
 		if u.Path == "mem" {
 			u.Scheme = "mem"
 			u.Path = ""
+		} else if strings.HasPrefix(u.Path, "/") {
+			u.Scheme = "file"
 		} else {
 			u.Scheme = "file"
-			u.Host = u.Path
+			fragments := strings.SplitN(u.Path, "/", 2)
+			u.Host = fragments[0]
 			u.Path = ""
+			if len(fragments) == 2 {
+				u.Path = fragments[1]
+				movePathToPrefix(u)
+			}
 		}
 	case "s3":
-		if _, ok := u.Query()["region"]; !ok {
-			found := false
-			for _, env := range environ {
-				if strings.HasPrefix(env, "AWS_REGION=") {
-					query := make(url.Values)
-					query.Set("region", env[len("AWS_REGION="):])
-					u.RawQuery = query.Encode()
-					found = true
-					break
-				}
-			}
-			if !found {
-				return "", fmt.Errorf("S3 URL '%s' doesn't have region query and no AWS_REGION env var", u.String())
-			}
+		return normalizeS3(u, "", env)
+	case "arn":
+		return normalizeS3ARN(u, env)
+	case "gs":
+		movePathToPrefix(u)
+	case "azblob":
+		return normalizeAzureBlob(u, env)
+	case "https":
+		return normalizeBlobHTTPS(u, env)
+	default:
+		if result, ok, err := normalizeRegistered(KindBlob, srcUrl, NormalizeOption{Environ: env}); ok {
+			return result, err
+		}
+	}
+	return u.String(), nil
+}
+
+func normalizeS3(u *url.URL, region string, env EnvSource) (string, error) {
+	movePathToPrefix(u)
+	q := u.Query()
+	if region != "" {
+		q.Set("region", region)
+	} else if _, ok := q["region"]; !ok {
+		region, found := lookupEnv(env, "AWS_REGION")
+		if !found {
+			return "", newNormalizeError(KindBlob, "s3", u.String(), "region", ErrMissingRegion, "S3 URL '%s' doesn't have region query and no AWS_REGION env var", u.String())
+		}
+		q.Set("region", region)
+	}
+	setAWSEndpoint(q, env, "S3", true)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// normalizeS3ARN converts arn:aws:s3:::(bucket) into s3 URL.
+func normalizeS3ARN(u *url.URL, env EnvSource) (string, error) {
+	fragments := strings.Split(u.Opaque, ":")
+	if len(fragments) != 5 || fragments[1] != "s3" || fragments[4] == "" {
+		return "", newNormalizeError(KindBlob, "arn", u.String(), "bucket", ErrMalformedURL, "S3 ARN should be arn:aws:s3:::(bucket), but '%s'", u.String())
+	}
+	resource := strings.SplitN(fragments[4], "/", 2)
+	s3URL := &url.URL{Scheme: "s3", Host: resource[0], RawQuery: u.RawQuery}
+	if len(resource) == 2 {
+		s3URL.Path = resource[1]
+	}
+	return normalizeS3(s3URL, "", env)
+}
+
+// normalizeBlobHTTPS converts native https URLs that are shown in cloud consoles into gocloud.dev URL.
+//
+// Unknown hosts are returned as is.
+func normalizeBlobHTTPS(u *url.URL, env EnvSource) (string, error) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case strings.HasSuffix(u.Host, azureBlobHostSuffix):
+		return normalizeAzureBlobHTTPS(u)
+	case u.Host == "s3.console.aws.amazon.com":
+		// https://s3.console.aws.amazon.com/s3/buckets/(bucket)?region=(region)
+		if len(segments) < 3 || segments[0] != "s3" || segments[1] != "buckets" || segments[2] == "" {
+			return "", newNormalizeError(KindBlob, "https", u.String(), "bucket", ErrMalformedURL, "S3 console URL should be https://s3.console.aws.amazon.com/s3/buckets/(bucket), but '%s'", u.String())
+		}
+		s3URL := &url.URL{Scheme: "s3", Host: segments[2], Path: strings.Join(segments[3:], "/")}
+		if prefix := u.Query().Get("prefix"); prefix != "" {
+			s3URL.RawQuery = url.Values{"prefix": []string{prefix}}.Encode()
+		}
+		return normalizeS3(s3URL, u.Query().Get("region"), env)
+	case strings.HasSuffix(u.Host, ".amazonaws.com"):
+		return normalizeS3HTTPS(u, segments, env)
+	case u.Host == "storage.googleapis.com" || u.Host == "storage.cloud.google.com":
+		// https://storage.googleapis.com/(bucket)
+		if segments[0] == "" {
+			return "", newNormalizeError(KindBlob, "https", u.String(), "bucket", ErrMalformedURL, "Cloud Storage URL should be https://%s/(bucket), but '%s'", u.Host, u.String())
+		}
+		gsURL := &url.URL{Scheme: "gs", Host: segments[0], Path: strings.Join(segments[1:], "/")}
+		movePathToPrefix(gsURL)
+		return gsURL.String(), nil
+	case u.Host == "console.cloud.google.com":
+		// https://console.cloud.google.com/storage/browser/(bucket)
+		if len(segments) < 3 || segments[0] != "storage" || segments[1] != "browser" || segments[2] == "" {
+			return "", newNormalizeError(KindBlob, "https", u.String(), "bucket", ErrMalformedURL, "Cloud Storage console URL should be https://console.cloud.google.com/storage/browser/(bucket), but '%s'", u.String())
 		}
+		gsURL := &url.URL{Scheme: "gs", Host: segments[2], Path: strings.Join(segments[3:], "/")}
+		movePathToPrefix(gsURL)
+		return gsURL.String(), nil
 	}
 	return u.String(), nil
 }
+
+// awsRegionPattern matches AWS region like "us-west-2" or "us-gov-west-1".
+var awsRegionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// normalizeS3HTTPS converts both virtual hosted style (https://(bucket).s3.(region).amazonaws.com)
+// and path style (https://s3.(region).amazonaws.com/(bucket)) URLs into s3 URL. Legacy (s3-(region)) and
+// website (s3-website-(region), s3-website.(region)) endpoints are also accepted.
+func normalizeS3HTTPS(u *url.URL, segments []string, env EnvSource) (string, error) {
+	labels := strings.Split(strings.TrimSuffix(u.Host, ".amazonaws.com"), ".")
+	s3Index := -1
+	for i, label := range labels {
+		if label == "s3" || strings.HasPrefix(label, "s3-") {
+			s3Index = i
+		}
+	}
+	if s3Index == -1 {
+		return "", newNormalizeError(KindBlob, "https", u.String(), "host", ErrMalformedURL, "'%s' is not a S3 endpoint", u.String())
+	}
+	var region string
+	switch marker := labels[s3Index]; {
+	case marker == "s3", marker == "s3-website", marker == "s3-fips":
+		// region is the next label: s3.(region), s3-website.(region), s3-fips.(region)
+	case strings.HasPrefix(marker, "s3-website-"):
+		region = marker[len("s3-website-"):]
+	case awsRegionPattern.MatchString(marker[len("s3-"):]):
+		// legacy endpoint: s3-(region)
+		region = marker[len("s3-"):]
+	default:
+		// s3-accesspoint, s3-control and so on are not bucket endpoints
+		return "", newNormalizeError(KindBlob, "https", u.String(), "host", ErrMalformedURL, "'%s' is not a S3 bucket endpoint", u.String())
+	}
+	for _, label := range labels[s3Index+1:] {
+		if label != "dualstack" {
+			region = label
+		}
+	}
+	var bucket string
+	if s3Index > 0 {
+		bucket = strings.Join(labels[:s3Index], ".")
+	} else {
+		bucket = segments[0]
+		segments = segments[1:]
+	}
+	if bucket == "" {
+		return "", newNormalizeError(KindBlob, "https", u.String(), "bucket", ErrMalformedURL, "S3 URL '%s' doesn't have bucket name", u.String())
+	}
+	return normalizeS3(&url.URL{Scheme: "s3", Host: bucket, Path: strings.Join(segments, "/")}, region, env)
+}
+
+const azureBlobHostSuffix = ".blob.core.windows.net"
+
+func normalizeAzureBlob(u *url.URL, env EnvSource) (string, error) {
+	if u.Host == "" {
+		return "", newNormalizeError(KindBlob, "azblob", u.String(), "container", ErrMalformedURL, "Azure Blob Storage URL '%s' doesn't have container name", u.String())
+	}
+	movePathToPrefix(u)
+	q := u.Query()
+	if q.Get("storage_account") == "" {
+		account, found := lookupEnv(env, "AZURE_STORAGE_ACCOUNT")
+		if !found || account == "" {
+			return "", newNormalizeError(KindBlob, "azblob", u.String(), "storage_account", ErrMissingEnv, "Azure Blob Storage URL '%s' doesn't have storage_account query and no AZURE_STORAGE_ACCOUNT env var", u.String())
+		}
+		q.Set("storage_account", account)
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
+// normalizeAzureBlobHTTPS converts https://(account).blob.core.windows.net/(container) into azblob URL.
+func normalizeAzureBlobHTTPS(u *url.URL) (string, error) {
+	account := strings.TrimSuffix(u.Host, azureBlobHostSuffix)
+	segments := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+	container := segments[0]
+	if account == "" || container == "" {
+		return "", newNormalizeError(KindBlob, "https", u.String(), "container", ErrMalformedURL, "Azure Blob Storage URL should be https://(account)%s/(container), but '%s'", azureBlobHostSuffix, u.String())
+	}
+	query := make(url.Values)
+	query.Set("storage_account", account)
+	result := &url.URL{
+		Scheme:   "azblob",
+		Host:     container,
+		RawQuery: query.Encode(),
+	}
+	if len(segments) == 2 {
+		result.Path = segments[1]
+		movePathToPrefix(result)
+	}
+	return result.String(), nil
+}
+
+// movePathToPrefix moves sub directory after bucket name into prefix query parameter.
+func movePathToPrefix(u *url.URL) {
+	dir := strings.Trim(u.Path, "/")
+	u.Path = ""
+	if dir == "" {
+		return
+	}
+	q := u.Query()
+	q.Set("prefix", dir+"/"+q.Get("prefix"))
+	u.RawQuery = q.Encode()
+}
+
+// joinBlobPrefix adds sub directory to base prefix.
+func joinBlobPrefix(base, sub string) string {
+	sub = strings.Trim(sub, "/")
+	if sub == "" {
+		return base
+	}
+	if base != "" && !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return base + sub + "/"
+}
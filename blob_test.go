@@ -57,10 +57,196 @@ func TestNormalizeBlobURL(t *testing.T) {
 			hasError: true,
 			environs: []string{},
 		},
+		{
+			name:     "azblob",
+			src:      "azblob://my-container?storage_account=myaccount",
+			hasError: false,
+			expected: "azblob://my-container?storage_account=myaccount",
+		},
+		{
+			name:     "azblob with env",
+			src:      "azblob://my-container",
+			hasError: false,
+			expected: "azblob://my-container?storage_account=myaccount",
+			environs: []string{"AZURE_STORAGE_ACCOUNT=myaccount"},
+		},
+		{
+			name:     "azblob error",
+			src:      "azblob://my-container",
+			hasError: true,
+			environs: []string{},
+		},
+		{
+			name:     "azblob native URL",
+			src:      "https://myaccount.blob.core.windows.net/my-container",
+			hasError: false,
+			expected: "azblob://my-container?storage_account=myaccount",
+		},
+		{
+			name:     "azblob native URL without container",
+			src:      "https://myaccount.blob.core.windows.net/",
+			hasError: true,
+		},
+		{
+			name:     "s3 ARN",
+			src:      "arn:aws:s3:::my-bucket",
+			hasError: false,
+			expected: "s3://my-bucket?region=us-west-1",
+			environs: []string{"AWS_REGION=us-west-1"},
+		},
+		{
+			name:     "s3 ARN error",
+			src:      "arn:aws:s3:::",
+			hasError: true,
+			environs: []string{"AWS_REGION=us-west-1"},
+		},
+		{
+			name:     "s3 virtual hosted style URL",
+			src:      "https://my-bucket.s3.us-west-2.amazonaws.com",
+			hasError: false,
+			expected: "s3://my-bucket?region=us-west-2",
+			environs: []string{"AWS_REGION=us-west-1"},
+		},
+		{
+			name:     "s3 virtual hosted style URL with dots",
+			src:      "https://my.bucket.s3-us-west-2.amazonaws.com/",
+			hasError: false,
+			expected: "s3://my.bucket?region=us-west-2",
+		},
+		{
+			name:     "s3 virtual hosted style URL without region",
+			src:      "https://my-bucket.s3.amazonaws.com",
+			hasError: false,
+			expected: "s3://my-bucket?region=us-west-1",
+			environs: []string{"AWS_REGION=us-west-1"},
+		},
+		{
+			name:     "s3 website endpoint (dash)",
+			src:      "https://b.s3-website-us-west-2.amazonaws.com",
+			hasError: false,
+			expected: "s3://b?region=us-west-2",
+		},
+		{
+			name:     "s3 website endpoint (dot)",
+			src:      "https://b.s3-website.eu-central-1.amazonaws.com",
+			hasError: false,
+			expected: "s3://b?region=eu-central-1",
+		},
+		{
+			name:     "s3 access point is not bucket",
+			src:      "https://myap-123456789012.s3-accesspoint.us-west-2.amazonaws.com",
+			hasError: true,
+		},
+		{
+			name:     "s3 path style URL",
+			src:      "https://s3.us-west-2.amazonaws.com/my-bucket",
+			hasError: false,
+			expected: "s3://my-bucket?region=us-west-2",
+		},
+		{
+			name:     "s3 path style URL without bucket",
+			src:      "https://s3.us-west-2.amazonaws.com/",
+			hasError: true,
+		},
+		{
+			name:     "s3 console URL",
+			src:      "https://s3.console.aws.amazon.com/s3/buckets/my-bucket?region=us-west-2&tab=objects",
+			hasError: false,
+			expected: "s3://my-bucket?region=us-west-2",
+		},
+		{
+			name:     "gs URL",
+			src:      "https://storage.googleapis.com/my-bucket",
+			hasError: false,
+			expected: "gs://my-bucket",
+		},
+		{
+			name:     "gs console URL",
+			src:      "https://console.cloud.google.com/storage/browser/my-bucket",
+			hasError: false,
+			expected: "gs://my-bucket",
+		},
+		{
+			name:     "gs with trailing slash",
+			src:      "gs://my-bucket/",
+			hasError: false,
+			expected: "gs://my-bucket",
+		},
+		{
+			name:     "file with sub directory",
+			src:      "folder/sub",
+			hasError: false,
+			expected: "file://folder?prefix=sub%2F",
+		},
+		{
+			name:     "file with absolute path",
+			src:      "/var/data",
+			hasError: false,
+			expected: "file:///var/data",
+		},
+		{
+			name:     "s3 with sub directory",
+			src:      "s3://my-bucket/tenant-a/uploads",
+			hasError: false,
+			expected: "s3://my-bucket?prefix=tenant-a%2Fuploads%2F&region=us-west-1",
+			environs: []string{"AWS_REGION=us-west-1"},
+		},
+		{
+			name:     "s3 ARN with sub directory",
+			src:      "arn:aws:s3:::my-bucket/tenant-a",
+			hasError: false,
+			expected: "s3://my-bucket?prefix=tenant-a%2F&region=us-west-1",
+			environs: []string{"AWS_REGION=us-west-1"},
+		},
+		{
+			name:     "s3 path style URL with sub directory",
+			src:      "https://s3.us-west-2.amazonaws.com/my-bucket/tenant-a/",
+			hasError: false,
+			expected: "s3://my-bucket?prefix=tenant-a%2F&region=us-west-2",
+		},
+		{
+			name:     "s3 console URL with prefix",
+			src:      "https://s3.console.aws.amazon.com/s3/buckets/my-bucket?region=us-west-2&prefix=tenant-a/",
+			hasError: false,
+			expected: "s3://my-bucket?prefix=tenant-a%2F&region=us-west-2",
+		},
+		{
+			name:     "gs with sub directory",
+			src:      "gs://my-bucket/tenant-a/uploads/",
+			hasError: false,
+			expected: "gs://my-bucket?prefix=tenant-a%2Fuploads%2F",
+		},
+		{
+			name:     "azblob native URL with sub directory",
+			src:      "https://myaccount.blob.core.windows.net/my-container/tenant-a",
+			hasError: false,
+			expected: "azblob://my-container?prefix=tenant-a%2F&storage_account=myaccount",
+		},
+		{
+			name:     "s3 with MinIO",
+			src:      "s3://my-bucket",
+			hasError: false,
+			expected: "s3://my-bucket?disableSSL=true&endpoint=http%3A%2F%2Flocalhost%3A9000&region=us-east-1&s3ForcePathStyle=true",
+			environs: []string{"AWS_REGION=us-east-1", "AWS_ENDPOINT_URL=http://localhost:9000"},
+		},
+		{
+			name:     "s3 with service specific endpoint",
+			src:      "s3://my-bucket",
+			hasError: false,
+			expected: "s3://my-bucket?endpoint=https%3A%2F%2Fminio.example.com&region=us-east-1&s3ForcePathStyle=true",
+			environs: []string{"AWS_REGION=us-east-1", "AWS_ENDPOINT_URL=http://localhost:4566", "AWS_ENDPOINT_URL_S3=https://minio.example.com"},
+		},
+		{
+			name:     "s3 with endpoint in query",
+			src:      "s3://my-bucket?endpoint=localhost:9000",
+			hasError: false,
+			expected: "s3://my-bucket?endpoint=localhost%3A9000&region=us-east-1",
+			environs: []string{"AWS_REGION=us-east-1", "AWS_ENDPOINT_URL=http://localhost:4566"},
+		},
 	}
 	for _, testcase := range testcases {
 		t.Run(testcase.name, func(t *testing.T) {
-			result, err := normalizeBlobURL(testcase.src, testcase.environs)
+			result, err := normalizeBlobURL(testcase.src, EnvList(testcase.environs))
 			if testcase.hasError {
 				assert.NotNil(t, err)
 			} else {
@@ -70,3 +256,46 @@ func TestNormalizeBlobURL(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeBlobURLWithOption(t *testing.T) {
+	testcases := []struct {
+		name     string
+		src      string
+		prefix   string
+		expected string
+	}{
+		{
+			name:     "without prefix",
+			src:      "gs://my-bucket",
+			prefix:   "",
+			expected: "gs://my-bucket",
+		},
+		{
+			name:     "add prefix",
+			src:      "gs://my-bucket",
+			prefix:   "tenant-a",
+			expected: "gs://my-bucket?prefix=tenant-a%2F",
+		},
+		{
+			name:     "add prefix to sub directory",
+			src:      "gs://my-bucket/uploads",
+			prefix:   "/tenant-a/",
+			expected: "gs://my-bucket?prefix=uploads%2Ftenant-a%2F",
+		},
+		{
+			name:     "mem",
+			src:      "mem",
+			prefix:   "tenant-a",
+			expected: "mem:?prefix=tenant-a%2F",
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := NormalizeBlobURL(testcase.src, nil, BlobOption{
+				Prefix: testcase.prefix,
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, testcase.expected, result)
+		})
+	}
+}
@@ -0,0 +1,283 @@
+// gocloudurls command normalizes config values into gocloud.dev URLs for shell scripts and CI.
+//
+//   gocloudurls blob s3://bucket
+//   gocloudurls docstore --collection tasks --partition-key job_id dynamodb://
+//   gocloudurls dynamodb-schema --collection tasks --partition-key job_id dynamodb://
+//   gocloudurls dynamodb-schema --format terraform dynamodb://tasks?partition_key=job_id
+//   cat urls.txt | gocloudurls topic --json
+//
+// Exit code is 0 if all inputs are valid, 1 if some inputs are invalid and 2 if arguments are wrong.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/future-architect/gocloudurls"
+)
+
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitUsage   = 2
+)
+
+const usage = `Usage: gocloudurls <command> [options] [url...]
+
+Commands:
+  detect           detect resource kind of urls and normalize them
+  blob             normalize gocloud.dev/blob url
+  docstore         normalize gocloud.dev/docstore url
+  topic            normalize gocloud.dev/pubsub topic url
+  subscription     normalize gocloud.dev/pubsub subscription url
+  secret           normalize gocloud.dev/secrets url
+  runtimevar       normalize gocloud.dev/runtimevar url
+  sql              normalize gocloud.dev/mysql or gocloud.dev/postgres url
+  dynamodb-schema  print AWS CLI command, CloudFormation or Terraform to create DynamoDB table
+
+If no url is given, urls are read from stdin (one per line).
+Run "gocloudurls <command> --help" to see options.
+`
+
+var commandKinds = map[string]gocloudurls.ResourceKind{
+	"detect":       "",
+	"blob":         gocloudurls.KindBlob,
+	"docstore":     gocloudurls.KindDocStore,
+	"topic":        gocloudurls.KindTopic,
+	"subscription": gocloudurls.KindSubscription,
+	"secret":       gocloudurls.KindSecret,
+	"runtimevar":   gocloudurls.KindRuntimeVar,
+	"sql":          gocloudurls.KindSQL,
+}
+
+// result is an output of each input in JSON mode.
+type result struct {
+	Input    string     `json:"input"`
+	Kind     string     `json:"kind,omitempty"`
+	URL      string     `json:"url,omitempty"`
+	Commands [][]string `json:"commands,omitempty"`
+	Output   string     `json:"output,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	command := args[0]
+	kind, ok := commandKinds[command]
+	if !ok && command != "dynamodb-schema" {
+		fmt.Fprintf(stderr, "unknown command: %s\n\n%s", command, usage)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("gocloudurls "+command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	jsonOutput := fs.Bool("json", false, "output results as JSON")
+	var opt gocloudurls.NormalizeOption
+	var schemaOpt gocloudurls.SchemaOption
+	var keyType, partitionKeyType, format string
+	switch command {
+	case "detect", "blob":
+		fs.StringVar(&opt.Blob.Prefix, "prefix", "", "prefix that is added to blob keys")
+	}
+	switch command {
+	case "detect", "docstore", "dynamodb-schema":
+		fs.StringVar(&opt.DocStore.Collection, "collection", "", "collection name")
+		fs.StringVar(&opt.DocStore.KeyName, "key", "", "key name (default _id)")
+		fs.StringVar(&opt.DocStore.PartitionKey, "partition-key", "", "partition key of DynamoDB")
+	}
+	switch command {
+	case "detect", "runtimevar":
+		fs.StringVar(&opt.RuntimeVar.Decoder, "decoder", "", "decoder of variable (string, json or bytes)")
+	}
+	switch command {
+	case "detect", "sql":
+		fs.StringVar(&opt.SQL.Database, "database", "", "database name")
+		fs.StringVar(&opt.SQL.Engine, "engine", "", "mysql or postgres (for Cloud SQL instance connection name)")
+	}
+	if command == "dynamodb-schema" {
+		fs.StringVar(&format, "format", "cli", "output format (cli, cloudformation, cloudformation-json, terraform or create-table-json)")
+		fs.StringVar(&keyType, "key-type", "S", "attribute type of key (S, N or B)")
+		fs.StringVar(&partitionKeyType, "partition-key-type", "S", "attribute type of partition key (S, N or B)")
+		fs.IntVar(&schemaOpt.ReadCapacityUnits, "read-capacity", 0, "read capacity units (default 5)")
+		fs.IntVar(&schemaOpt.WriteCapacityUnits, "write-capacity", 0, "write capacity units (default 5)")
+		fs.StringVar(&schemaOpt.BillingMode, "billing-mode", "", "PROVISIONED (default) or PAY_PER_REQUEST")
+		fs.StringVar(&schemaOpt.StreamViewType, "stream", "", "stream view type (NEW_IMAGE, OLD_IMAGE, NEW_AND_OLD_IMAGES or KEYS_ONLY)")
+		fs.BoolVar(&schemaOpt.SSE, "sse", false, "encrypt table by AWS managed KMS key")
+		fs.StringVar(&schemaOpt.KMSKeyID, "kms-key", "", "KMS key to encrypt table (key ARN for terraform format)")
+		fs.StringVar(&schemaOpt.TableClass, "table-class", "", "STANDARD or STANDARD_INFREQUENT_ACCESS")
+		fs.Var(tagsFlag{&schemaOpt.Tags}, "tag", "tag of table as key=value (can be repeated)")
+		fs.StringVar(&schemaOpt.TTLAttribute, "ttl", "", "attribute name of time to live")
+		fs.BoolVar(&schemaOpt.PointInTimeRecovery, "pitr", false, "enable point-in-time recovery")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if command == "dynamodb-schema" && !dynamoDBSchemaFormats[format] {
+		fmt.Fprintf(stderr, "unknown format: %s\n", format)
+		return exitUsage
+	}
+
+	inputs := fs.Args()
+	if len(inputs) == 0 {
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				inputs = append(inputs, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+	}
+	if len(inputs) == 0 {
+		fmt.Fprintf(stderr, "no url is given\n")
+		return exitUsage
+	}
+
+	code := exitOK
+	results := make([]result, 0, len(inputs))
+	for _, input := range inputs {
+		r := result{Input: input}
+		var err error
+		if command == "dynamodb-schema" {
+			var schema *gocloudurls.DynamoDBSchema
+			r.URL, schema, err = dynamoDBSchema(input, opt.DocStore, keyType, partitionKeyType)
+			if err == nil {
+				r.Commands, r.Output, err = renderDynamoDBSchema(schema, format, schemaOpt)
+			}
+		} else {
+			k := kind
+			if k == "" {
+				k, err = gocloudurls.DetectKind(input)
+			}
+			if err == nil {
+				r.Kind = string(k)
+				r.URL, err = gocloudurls.Normalize(k, input, opt)
+			}
+		}
+		if err != nil {
+			r.Error = err.Error()
+			code = exitInvalid
+		}
+		results = append(results, r)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(results)
+		return code
+	}
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			fmt.Fprintf(stderr, "%s: %s\n", r.Input, r.Error)
+		case r.Commands != nil:
+			for _, command := range r.Commands {
+				fmt.Fprintln(stdout, shellJoin(command))
+			}
+		case r.Output != "":
+			fmt.Fprintln(stdout, strings.TrimSuffix(r.Output, "\n"))
+		default:
+			fmt.Fprintln(stdout, r.URL)
+		}
+	}
+	return code
+}
+
+// dynamoDBSchema normalizes DynamoDB URL and returns schema of the table.
+func dynamoDBSchema(input string, opt gocloudurls.Option, keyType, partitionKeyType string) (string, *gocloudurls.DynamoDBSchema, error) {
+	loc, err := gocloudurls.ParseDocStoreURL(input, opt)
+	if err != nil {
+		return "", nil, err
+	}
+	if loc.Provider != "dynamodb" {
+		return "", nil, fmt.Errorf("dynamodb-schema only supports dynamodb url, but '%s'", loc.String())
+	}
+	schema := gocloudurls.DynamoDBSchema{
+		Collection:        loc.Collection,
+		PartitionKeyField: &gocloudurls.Field{Name: loc.PartitionKey, Type: partitionKeyType},
+	}
+	if loc.SortKey != "" {
+		schema.SortKeyField = &gocloudurls.Field{Name: loc.SortKey, Type: keyType}
+	} else {
+		// key is used as a partition key if partition key is not specified
+		schema.PartitionKeyField.Type = keyType
+	}
+	return loc.String(), &schema, nil
+}
+
+var dynamoDBSchemaFormats = map[string]bool{
+	"cli":                 true,
+	"cloudformation":      true,
+	"cloudformation-json": true,
+	"terraform":           true,
+	"create-table-json":   true,
+}
+
+// renderDynamoDBSchema returns AWS CLI commands (cli format) or text of other formats.
+func renderDynamoDBSchema(schema *gocloudurls.DynamoDBSchema, format string, opt gocloudurls.SchemaOption) ([][]string, string, error) {
+	switch format {
+	case "cloudformation":
+		return nil, schema.CloudFormationYAML(opt), nil
+	case "cloudformation-json":
+		return nil, schema.CloudFormationJSON(opt), nil
+	case "terraform":
+		output, err := schema.TerraformHCL(opt)
+		return nil, output, err
+	case "create-table-json":
+		return nil, schema.CreateTableJSON(opt), nil
+	}
+	return schema.SetupCommands(opt), "", nil
+}
+
+// tagsFlag is a flag.Value that collects key=value pairs.
+type tagsFlag struct {
+	tags *map[string]string
+}
+
+func (t tagsFlag) String() string {
+	return ""
+}
+
+func (t tagsFlag) Set(value string) error {
+	keyValue := strings.SplitN(value, "=", 2)
+	if len(keyValue) != 2 || keyValue[0] == "" {
+		return fmt.Errorf("tag should be key=value, but '%s'", value)
+	}
+	if *t.tags == nil {
+		*t.tags = map[string]string{}
+	}
+	(*t.tags)[keyValue[0]] = keyValue[1]
+	return nil
+}
+
+// shellJoin joins command line arguments with quoting for shell.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && strings.IndexFunc(arg, needsQuote) == -1 {
+			quoted[i] = arg
+		} else {
+			quoted[i] = "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func needsQuote(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_=,./:@%+", r))
+}
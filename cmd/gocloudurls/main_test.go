@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	testcases := []struct {
+		name     string
+		args     []string
+		stdin    string
+		code     int
+		expected string
+	}{
+		{
+			name:     "blob",
+			args:     []string{"blob", "--prefix", "tenant-a", "gs://my-bucket"},
+			expected: "gs://my-bucket?prefix=tenant-a%2F\n",
+		},
+		{
+			name:     "docstore",
+			args:     []string{"docstore", "--collection", "tasks", "--partition-key", "job_id", "dynamodb://"},
+			expected: "dynamodb://tasks?partition_key=job_id&sort_key=_id\n",
+		},
+		{
+			name:     "stdin",
+			args:     []string{"topic"},
+			stdin:    "arn:aws:sns:us-east-2:123456789012:a\n\narn:aws:sns:us-east-2:123456789012:b\n",
+			expected: "awssns:///arn:aws:sns:us-east-2:123456789012:a?region=us-east-2\nawssns:///arn:aws:sns:us-east-2:123456789012:b?region=us-east-2\n",
+		},
+		{
+			name:     "detect",
+			args:     []string{"detect", "projects/myproject/topics/mytopic"},
+			expected: "gcppubsub://projects/myproject/topics/mytopic\n",
+		},
+		{
+			name:     "dynamodb-schema",
+			args:     []string{"dynamodb-schema", "--collection", "tasks", "--partition-key", "job_id", "--key-type", "N", "dynamodb://"},
+			expected: "aws dynamodb create-table --table-name tasks --attribute-definitions AttributeName=job_id,AttributeType=S AttributeName=_id,AttributeType=N --key-schema AttributeName=job_id,KeyType=HASH AttributeName=_id,KeyType=RANGE --provisioned-throughput ReadCapacityUnits=5,WriteCapacityUnits=5\n",
+		},
+		{
+			name: "dynamodb-schema with settings",
+			args: []string{"dynamodb-schema", "--billing-mode", "PAY_PER_REQUEST", "--tag", "team=backend", "--ttl", "expires_at", "dynamodb://tasks"},
+			expected: "aws dynamodb create-table --table-name tasks --attribute-definitions AttributeName=_id,AttributeType=S --key-schema AttributeName=_id,KeyType=HASH --billing-mode PAY_PER_REQUEST --tags Key=team,Value=backend\n" +
+				"aws dynamodb update-time-to-live --table-name tasks --time-to-live-specification Enabled=true,AttributeName=expires_at\n",
+		},
+		{
+			name: "dynamodb-schema terraform",
+			args: []string{"dynamodb-schema", "--format", "terraform", "--billing-mode", "PAY_PER_REQUEST", "dynamodb://tasks"},
+			expected: `resource "aws_dynamodb_table" "tasks" {
+  name         = "tasks"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "_id"
+
+  attribute {
+    name = "_id"
+    type = "S"
+  }
+}
+`,
+		},
+		{
+			name: "dynamodb-schema terraform with KMS key alias",
+			args: []string{"dynamodb-schema", "--format", "terraform", "--kms-key", "alias/my-key", "dynamodb://tasks"},
+			code: exitInvalid,
+		},
+		{
+			name: "unknown format",
+			args: []string{"dynamodb-schema", "--format", "pulumi", "dynamodb://tasks"},
+			code: exitUsage,
+		},
+		{
+			name:     "invalid url",
+			args:     []string{"sql", "sqlite://db"},
+			code:     exitInvalid,
+			expected: "",
+		},
+		{
+			name: "unknown command",
+			args: []string{"queue", "mem://"},
+			code: exitUsage,
+		},
+		{
+			name: "unknown flag",
+			args: []string{"blob", "--collection", "tasks", "mem"},
+			code: exitUsage,
+		},
+		{
+			name: "no input",
+			args: []string{"blob"},
+			code: exitUsage,
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(testcase.args, strings.NewReader(testcase.stdin), &stdout, &stderr)
+			assert.Equal(t, testcase.code, code, stderr.String())
+			assert.Equal(t, testcase.expected, stdout.String())
+		})
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"secret", "--json", "projects/p/locations/l/keyRings/r/cryptoKeys/k", "base64key://short"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, exitInvalid, code)
+	var results []result
+	assert.Nil(t, json.Unmarshal(stdout.Bytes(), &results))
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, "gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k", results[0].URL)
+	assert.Equal(t, "secret", results[0].Kind)
+	assert.NotEqual(t, "", results[1].Error)
+}
+
+func TestShellJoin(t *testing.T) {
+	assert.Equal(t, `aws --table-name tasks 'it'\''s' ''`, shellJoin([]string{"aws", "--table-name", "tasks", "it's", ""}))
+}
@@ -1,8 +1,6 @@
 package gocloudurls
 
 import (
-	"errors"
-	"fmt"
 	"net/url"
 	"path"
 	"strings"
@@ -17,12 +15,16 @@ import (
 //
 // If Collection is specified, it returns URL for the Collection. It is good for applications that uses multiple
 // collections.
+//
+// Environ is a source of environment variables to fill default values. If it is nil, environment variables
+// of current process are used.
 type Option struct {
 	KeyName       string
 	PartitionKey  string
 	Collection    string
 	FileName      string
 	RevisionField string
+	Environ       EnvSource
 }
 
 // NormalizeDocStoreURL normalizes Document Store URL
@@ -35,6 +37,12 @@ type Option struct {
 // If ``PartitionKey`` is specified for DynamoDB, ``KeyName`` is specified as ``sort_key``.
 // This config is ignored for other DocStores.
 //
+// If Firestore URL doesn't have project name (like "firestore:///jobs"), it is got from GOOGLE_CLOUD_PROJECT,
+// GCLOUD_PROJECT, CLOUDSDK_CORE_PROJECT environment variables or the credentials JSON file that
+// GOOGLE_APPLICATION_CREDENTIALS points to.
+//
+// If MongoDB URL doesn't have database name, it is read from the path of MONGO_SERVER_URL environment variable.
+//
 // Examples:
 //
 //   goclodurls.NormalizePubSubURL("mem://", goclodurls.Option{
@@ -65,6 +73,20 @@ func NormalizeDocStoreURL(srcUrl string, opt ...Option) (string, error) {
 	if len(opt) > 0 {
 		o = opt[0]
 	}
+	result, err := normalizeDocStoreURL(srcUrl, o)
+	return result, withInput(err, srcUrl)
+}
+
+// MustNormalizeDocStoreURL is similar to NormalizeDocStoreURL but raise panic if there is error
+func MustNormalizeDocStoreURL(srcUrl string, opt ...Option) string {
+	result, err := NormalizeDocStoreURL(srcUrl, opt...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func normalizeDocStoreURL(srcUrl string, o Option) (string, error) {
 	u, err := url.Parse(srcUrl)
 	if err != nil {
 		return "", err
@@ -73,27 +95,21 @@ func NormalizeDocStoreURL(srcUrl string, opt ...Option) (string, error) {
 	case "mem":
 		return normalizeMemstore(u, o.KeyName, o.Collection, o.FileName, o.RevisionField)
 	case "firestore":
-		return normalizeFirestore(u, o.KeyName, o.Collection)
+		return normalizeFirestore(u, o.KeyName, o.Collection, envOrOS([]EnvSource{o.Environ}))
 	case "dynamodb":
 		return normalizeDynamo(u, o.KeyName, o.PartitionKey, o.Collection)
 	case "mongo":
-		return normalizeMongo(u, o.KeyName, o.Collection)
+		return normalizeMongo(u, o.KeyName, o.Collection, envOrOS([]EnvSource{o.Environ}))
 	}
-	return "", fmt.Errorf("Unknown scheme of docstore: '%s'", u.Scheme)
-}
-
-// MustNormalizeDocStoreURL is similar to NormalizeDocStoreURL but raise panic if there is error
-func MustNormalizeDocStoreURL(srcUrl string, opt ...Option) string {
-	result, err := NormalizeDocStoreURL(srcUrl, opt...)
-	if err != nil {
-		panic(err)
+	if result, ok, err := normalizeRegistered(KindDocStore, srcUrl, NormalizeOption{Environ: o.Environ, DocStore: o}); ok {
+		return result, err
 	}
-	return result
+	return "", newNormalizeError(KindDocStore, u.Scheme, srcUrl, "scheme", ErrUnknownScheme, "Unknown scheme of docstore: '%s'", u.Scheme)
 }
 
 func normalizeMemstore(u *url.URL, keyName, collection, filename, revision string) (string, error) {
 	if collection == "" && u.Host == "" {
-		return "", errors.New("opt.Collection is required if source URL doesn't have Collection")
+		return "", newNormalizeError(KindDocStore, "mem", u.String(), "collection", ErrMissingCollection, "opt.Collection is required if source URL doesn't have Collection")
 	}
 	if collection != "" {
 		u.Host = collection
@@ -114,7 +130,17 @@ func normalizeMemstore(u *url.URL, keyName, collection, filename, revision strin
 	return u.String(), nil
 }
 
-func normalizeFirestore(u *url.URL, keyName, collection string) (string, error) {
+func normalizeFirestore(u *url.URL, keyName, collection string, env EnvSource) (string, error) {
+	if u.Host == "" {
+		// project is omitted: firestore:///jobs
+		if project, ok := gcpProject(env); ok {
+			u, _ = url.Parse(u.String())
+			u.Host = project
+			if u.Path == "/" {
+				u.Path = ""
+			}
+		}
+	}
 	if collection == "" {
 		return normalizeFirestoreWithInnerCollection(u, keyName)
 	} else {
@@ -124,20 +150,23 @@ func normalizeFirestore(u *url.URL, keyName, collection string) (string, error)
 
 func normalizeFirestoreWithInnerCollection(u *url.URL, keyName string) (string, error) {
 	u, _ = url.Parse(u.String())
+	shortForm := u.Host != "projects"
 	if u.Host == "" {
-		return "", fmt.Errorf("Firestore URL doesn't have project information: %s", u.String())
-	} else if u.Host != "projects" {
+		return "", newNormalizeError(KindDocStore, "firestore", u.String(), "project", ErrMissingProject, "Firestore URL doesn't have project information: %s", u.String())
+	} else if shortForm {
 		u.Path = path.Join("/", u.Host, u.Path)
 		u.Host = "projects"
 	}
 	elements := strings.Split(u.Path, "/")
-	switch len(elements) {
-	case 4:
+	switch {
+	case shortForm && len(elements) == 3:
+		u.Path = path.Join("/", elements[1], "databases", "(default)", "documents", elements[2])
+	case len(elements) == 4:
 		u.Path = path.Join("/", elements[1], "databases", elements[2], "documents", elements[3])
-	case 6:
+	case len(elements) == 6:
 		u.Path = path.Join("/", elements[1], "databases", elements[3], "documents", elements[5])
 	default:
-		return "", fmt.Errorf("Firestroe URL should be firestore://(prj)/(db)/(docs) or firestore://projects/(prj)/databases/(db)/documents/(docs), but '%s'", u.String())
+		return "", newNormalizeError(KindDocStore, "firestore", u.String(), "path", ErrMalformedURL, "Firestore URL should be firestore://(prj)/(docs), firestore://(prj)/(db)/(docs) or firestore://projects/(prj)/databases/(db)/documents/(docs), but '%s'", u.String())
 	}
 	query := make(url.Values)
 	if u.Query().Get("name_field") == "" && keyName == "" {
@@ -155,7 +184,7 @@ func normalizeFirestoreWithInnerCollection(u *url.URL, keyName string) (string,
 func normalizeFirestoreWithOuterCollection(u *url.URL, keyName, collection string) (string, error) {
 	u, _ = url.Parse(u.String())
 	if u.Host == "" {
-		return "", fmt.Errorf("Firestore URL doesn't have project information: %s", u.String())
+		return "", newNormalizeError(KindDocStore, "firestore", u.String(), "project", ErrMissingProject, "Firestore URL doesn't have project information: %s", u.String())
 	} else if u.Host != "projects" {
 		u.Path = path.Join("/", u.Host, u.Path)
 		u.Host = "projects"
@@ -166,12 +195,14 @@ func normalizeFirestoreWithOuterCollection(u *url.URL, keyName, collection strin
 		u.Path = path.Join("/", elements[1], "databases", "(default)", "documents", collection)
 	case 3:
 		u.Path = path.Join("/", elements[1], "databases", elements[2], "documents", collection)
-	case 4:
-		fallthrough
-	case 5:
+	case 4, 5:
+		if elements[2] != "databases" || (len(elements) == 5 && elements[4] != "documents") {
+			// firestore://(project)/(database)/(docs) already has collection
+			return "", newNormalizeError(KindDocStore, "firestore", u.String(), "path", ErrMalformedURL, "Firestore URL should be firestore://(project) or firestore://(project)/(database) or firestore://projects/(project)/databases/(database)/documents, but '%s'", u.String())
+		}
 		u.Path = path.Join("/", elements[1], "databases", elements[3], "documents", collection)
 	default:
-		return "", fmt.Errorf("Firestroe URL should be firestore://(project) or firestore://(project)/(database) or firestore://projects/(project)/databases/(database)/documents, but '%s'", u.String())
+		return "", newNormalizeError(KindDocStore, "firestore", u.String(), "path", ErrMalformedURL, "Firestore URL should be firestore://(project) or firestore://(project)/(database) or firestore://projects/(project)/databases/(database)/documents, but '%s'", u.String())
 	}
 	query := make(url.Values)
 	if u.Query().Get("name_field") == "" && keyName == "" {
@@ -188,7 +219,7 @@ func normalizeFirestoreWithOuterCollection(u *url.URL, keyName, collection strin
 
 func normalizeDynamo(u *url.URL, keyName, partitionKey, collection string) (string, error) {
 	if u.Host == "" && collection == "" {
-		return "", errors.New("opt.Collection is required if source URL doesn't have Collection")
+		return "", newNormalizeError(KindDocStore, "dynamodb", u.String(), "collection", ErrMissingCollection, "opt.Collection is required if source URL doesn't have Collection")
 	}
 	u.Scheme = "dynamodb"
 	if collection != "" {
@@ -226,12 +257,15 @@ func normalizeDynamo(u *url.URL, keyName, partitionKey, collection string) (stri
 	return u.String(), nil
 }
 
-func normalizeMongo(u *url.URL, keyName, collection string) (string, error) {
+func normalizeMongo(u *url.URL, keyName, collection string, env EnvSource) (string, error) {
+	if u.Host == "" {
+		u.Host = mongoDatabaseFromEnv(env)
+	}
 	if u.Host == "" {
-		return "", errors.New("mongo requires hostname as a database name, but empty")
+		return "", newNormalizeError(KindDocStore, "mongo", u.String(), "database", ErrMissingDatabase, "mongo requires hostname as a database name, but empty")
 	}
 	if u.Path == "/" && collection == "" {
-		return "", errors.New("opt.Collection is required if source URL doesn't have Collection")
+		return "", newNormalizeError(KindDocStore, "mongo", u.String(), "collection", ErrMissingCollection, "opt.Collection is required if source URL doesn't have Collection")
 	}
 	u, _ = url.Parse(u.String())
 	if u.Path == "" {
@@ -248,3 +282,16 @@ func normalizeMongo(u *url.URL, keyName, collection string) (string, error) {
 	u.RawQuery = query.Encode()
 	return u.String(), nil
 }
+
+// mongoDatabaseFromEnv returns database name in the path of MONGO_SERVER_URL like "mongodb://localhost:27017/my-db".
+func mongoDatabaseFromEnv(env EnvSource) string {
+	serverURL, ok := lookupEnv(env, "MONGO_SERVER_URL")
+	if !ok {
+		return ""
+	}
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(u.Path, "/")
+}
@@ -14,6 +14,7 @@ func TestFixFirestore(t *testing.T) {
 		src        string
 		collection string
 		keyName    string
+		environ    map[string]string
 		expected   string
 	}{
 		{
@@ -63,6 +64,12 @@ func TestFixFirestore(t *testing.T) {
 			src:        "firestore://projects/my-project/databases/my-database/documents/my-document",
 			collection: "jobs",
 		},
+		{
+			name:       "short form with collection and Collection",
+			hasError:   true,
+			src:        "firestore://my-project/(default)/addresses",
+			collection: "jobs",
+		},
 		{
 			name:       "with Collection",
 			hasError:   false,
@@ -89,10 +96,17 @@ func TestFixFirestore(t *testing.T) {
 			collection: "",
 			expected:   "firestore://projects/my-project/databases/my-database/documents/jobs?name_field=_id",
 		},
+		{
+			name:       "with Collection (short form): default database",
+			hasError:   false,
+			src:        "firestore://my-project/jobs",
+			collection: "",
+			expected:   "firestore://projects/my-project/databases/(default)/documents/jobs?name_field=_id",
+		},
 		{
 			name:       "with Collection (short form): too short error",
 			hasError:   true,
-			src:        "firestore://my-project/my-database",
+			src:        "firestore://my-project",
 			collection: "",
 		},
 		{
@@ -116,11 +130,27 @@ func TestFixFirestore(t *testing.T) {
 			collection: "",
 			expected:   "firestore://projects/my-project/databases/my-database/documents/jobs?name_field=id",
 		},
+		{
+			name:       "project from env",
+			hasError:   false,
+			src:        "firestore:///jobs",
+			collection: "",
+			environ:    map[string]string{"GOOGLE_CLOUD_PROJECT": "my-project"},
+			expected:   "firestore://projects/my-project/databases/(default)/documents/jobs?name_field=_id",
+		},
+		{
+			name:       "project from env with Collection",
+			hasError:   false,
+			src:        "firestore://",
+			collection: "jobs",
+			environ:    map[string]string{"GCLOUD_PROJECT": "my-project"},
+			expected:   "firestore://projects/my-project/databases/(default)/documents/jobs?name_field=_id",
+		},
 	}
 	for _, testcase := range testcases {
 		t.Run(testcase.name, func(t *testing.T) {
 			u, _ := url.Parse(testcase.src)
-			result, err := normalizeFirestore(u, testcase.keyName, testcase.collection)
+			result, err := normalizeFirestore(u, testcase.keyName, testcase.collection, EnvMap(testcase.environ))
 			if !testcase.hasError {
 				assert.Nil(t, err)
 				assert.Equal(t, testcase.expected, result)
@@ -298,6 +328,7 @@ func TestNormalizeMongo(t *testing.T) {
 		src        string
 		collection string
 		keyName    string
+		environ    map[string]string
 		hasError   bool
 		expected   string
 	}{
@@ -333,11 +364,26 @@ func TestNormalizeMongo(t *testing.T) {
 			hasError:   false,
 			expected:   "mongo://my-db/tasks?id_field=id",
 		},
+		{
+			name:       "database from MONGO_SERVER_URL",
+			src:        "mongo://",
+			collection: "tasks",
+			environ:    map[string]string{"MONGO_SERVER_URL": "mongodb://localhost:27017/my-db"},
+			hasError:   false,
+			expected:   "mongo://my-db/tasks?id_field=_id",
+		},
+		{
+			name:       "error: no database",
+			src:        "mongo://",
+			collection: "tasks",
+			environ:    map[string]string{"MONGO_SERVER_URL": "mongodb://localhost:27017"},
+			hasError:   true,
+		},
 	}
 	for _, testcase := range testcases {
 		t.Run(testcase.name, func(t *testing.T) {
 			u, _ := url.Parse(testcase.src)
-			result, err := normalizeMongo(u, testcase.keyName, testcase.collection)
+			result, err := normalizeMongo(u, testcase.keyName, testcase.collection, EnvMap(testcase.environ))
 			if testcase.hasError {
 				assert.NotNil(t, err)
 			} else {
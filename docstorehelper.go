@@ -0,0 +1,255 @@
+package gocloudurls
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DocStoreIndex is an index of Firestore or MongoDB that is specified by ``index`` tag.
+type DocStoreIndex struct {
+	Name   string
+	Fields []IndexField
+	Unique bool
+}
+
+// IndexField is a field of DocStoreIndex. Order is "asc", "desc" or "array" (array-contains of Firestore).
+type IndexField struct {
+	Name  string
+	Order string
+}
+
+// FirestoreSchema creates firestore.indexes.json for Firestore CLI.
+//
+// Indexes are specified by ``index`` tag. Fields of composite index are ordered as struct fields.
+// Use ";" to specify multiple indexes for a field:
+//
+//   type Task struct {
+//       ID        string    `docstore:"id"`
+//       Owner     string    `docstore:"owner" index:"by_owner"`
+//       Status    string    `docstore:"status" index:"by_owner;by_status"`
+//       CreatedAt time.Time `docstore:"created_at" index:"by_owner,desc;by_status,desc"`
+//   }
+//
+//   fs, err := NewFirestoreSchema(&Task{}, "firestore://my-project/tasks?name_field=id")
+//   fs.IndexesJSON()
+type FirestoreSchema struct {
+	Collection string
+	NameField  string
+	Indexes    []*DocStoreIndex
+}
+
+// MongoSchema creates mongosh commands to create indexes.
+//
+// It uses the same ``index`` tag as FirestoreSchema. ``unique`` option makes unique index:
+//
+//   type User struct {
+//       ID    string `docstore:"id"`
+//       Email string `docstore:"email" index:"by_email,unique"`
+//   }
+//
+//   ms, err := NewMongoSchema(&User{}, "mongo://my-db/users?id_field=id")
+//   ms.CreateIndexCommands()
+//   // db.getSiblingDB("my-db").getCollection("users").createIndex({"email": 1}, {"name": "by_email", "unique": true})
+type MongoSchema struct {
+	Database   string
+	Collection string
+	IDField    string
+	Indexes    []*DocStoreIndex
+}
+
+// NewFirestoreSchema creates FirestoreSchema from struct and Firestore URL.
+func NewFirestoreSchema(collectionEntity interface{}, urlString string) (*FirestoreSchema, error) {
+	loc, indexes, err := parseDocStoreSchema(collectionEntity, urlString, "firestore")
+	if err != nil {
+		return nil, err
+	}
+	return &FirestoreSchema{
+		Collection: loc.Collection,
+		NameField:  loc.PartitionKey,
+		Indexes:    indexes,
+	}, nil
+}
+
+// NewMongoSchema creates MongoSchema from struct and MongoDB URL.
+func NewMongoSchema(collectionEntity interface{}, urlString string) (*MongoSchema, error) {
+	loc, indexes, err := parseDocStoreSchema(collectionEntity, urlString, "mongo")
+	if err != nil {
+		return nil, err
+	}
+	return &MongoSchema{
+		Database:   loc.Database,
+		Collection: loc.Collection,
+		IDField:    loc.PartitionKey,
+		Indexes:    indexes,
+	}, nil
+}
+
+type firestoreIndexField struct {
+	FieldPath   string `json:"fieldPath"`
+	Order       string `json:"order,omitempty"`
+	ArrayConfig string `json:"arrayConfig,omitempty"`
+}
+
+type firestoreIndex struct {
+	CollectionGroup string                `json:"collectionGroup"`
+	QueryScope      string                `json:"queryScope"`
+	Fields          []firestoreIndexField `json:"fields"`
+}
+
+// IndexesJSON returns firestore.indexes.json that is deployed by "firebase deploy --only firestore:indexes".
+//
+// Single field indexes are skipped because Firestore creates them automatically. Name field is the document ID,
+// so it is converted into "__name__". Unique option is ignored.
+func (f FirestoreSchema) IndexesJSON() string {
+	var document struct {
+		Indexes        []firestoreIndex `json:"indexes"`
+		FieldOverrides []interface{}    `json:"fieldOverrides"`
+	}
+	document.Indexes = []firestoreIndex{}
+	document.FieldOverrides = []interface{}{}
+	for _, index := range f.Indexes {
+		if len(index.Fields) < 2 {
+			continue
+		}
+		result := firestoreIndex{CollectionGroup: f.Collection, QueryScope: "COLLECTION"}
+		for _, field := range index.Fields {
+			path := field.Name
+			if path == f.NameField {
+				path = "__name__"
+			}
+			switch field.Order {
+			case "desc":
+				result.Fields = append(result.Fields, firestoreIndexField{FieldPath: path, Order: "DESCENDING"})
+			case "array":
+				result.Fields = append(result.Fields, firestoreIndexField{FieldPath: path, ArrayConfig: "CONTAINS"})
+			default:
+				result.Fields = append(result.Fields, firestoreIndexField{FieldPath: path, Order: "ASCENDING"})
+			}
+		}
+		document.Indexes = append(document.Indexes, result)
+	}
+	b, _ := json.MarshalIndent(document, "", "  ")
+	return string(b)
+}
+
+// CreateIndexCommands returns mongosh commands to create indexes.
+//
+// Key field doesn't need an index because mongodocstore stores id_field as "_id" that MongoDB indexes as unique
+// by default. For the same reason, id_field in indexes is converted into "_id".
+func (m MongoSchema) CreateIndexCommands() []string {
+	collection := fmt.Sprintf("db.getSiblingDB(%s).getCollection(%s)", quoteJSON(m.Database), quoteJSON(m.Collection))
+	var result []string
+	for _, index := range m.Indexes {
+		keys := make([]string, len(index.Fields))
+		for i, field := range index.Fields {
+			direction := 1
+			if field.Order == "desc" {
+				direction = -1
+			}
+			name := field.Name
+			if name == m.IDField {
+				name = "_id"
+			}
+			keys[i] = fmt.Sprintf("%s: %d", quoteJSON(name), direction)
+		}
+		options := `"name": ` + quoteJSON(index.Name)
+		if index.Unique {
+			options += `, "unique": true`
+		}
+		result = append(result, fmt.Sprintf("%s.createIndex({%s}, {%s})", collection, strings.Join(keys, ", "), options))
+	}
+	return result
+}
+
+// parseDocStoreSchema parses URL and index tags of struct.
+func parseDocStoreSchema(collectionEntity interface{}, urlString, provider string) (*CollectionLocation, []*DocStoreIndex, error) {
+	sv := reflect.Indirect(reflect.ValueOf(collectionEntity))
+	if sv.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("collectionEntity should be struct interface or its pointer but: %T", collectionEntity)
+	}
+	loc, err := ParseDocStoreURL(urlString)
+	if err != nil {
+		return nil, nil, err
+	}
+	if loc.Provider != provider {
+		return nil, nil, fmt.Errorf("%s schema only supports %s: scheme, but %s", provider, provider, loc.Provider)
+	}
+	var indexes []*DocStoreIndex
+	hasKey := false
+	for i := 0; i < sv.Type().NumField(); i++ {
+		f := sv.Type().Field(i)
+		fieldName, ok := docstoreFieldName(f)
+		if !ok {
+			continue
+		}
+		if fieldName == loc.PartitionKey {
+			hasKey = true
+		}
+		if tag, ok := f.Tag.Lookup("index"); ok {
+			if err := addDocStoreIndexes(&indexes, fieldName, tag); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if !hasKey {
+		return nil, nil, fmt.Errorf("key field '%s' is not in struct %s", loc.PartitionKey, sv.Type().String())
+	}
+	return loc, indexes, nil
+}
+
+func addDocStoreIndexes(indexes *[]*DocStoreIndex, fieldName, tag string) error {
+	for _, spec := range strings.Split(tag, ";") {
+		elements := strings.Split(spec, ",")
+		if elements[0] == "" {
+			return fmt.Errorf("index tag should be (index)[,asc|desc|array][,unique], but '%s'", spec)
+		}
+		var index *DocStoreIndex
+		for _, existing := range *indexes {
+			if existing.Name == elements[0] {
+				index = existing
+				break
+			}
+		}
+		if index == nil {
+			index = &DocStoreIndex{Name: elements[0]}
+			*indexes = append(*indexes, index)
+		}
+		field := IndexField{Name: fieldName, Order: "asc"}
+		for _, option := range elements[1:] {
+			switch option {
+			case "asc", "desc", "array":
+				field.Order = option
+			case "unique":
+				index.Unique = true
+			default:
+				return fmt.Errorf("option of index should be asc, desc, array or unique, but '%s'", option)
+			}
+		}
+		if field.Order == "array" {
+			for _, existing := range index.Fields {
+				if existing.Order == "array" {
+					return fmt.Errorf("index '%s' has multiple array fields", index.Name)
+				}
+			}
+		}
+		index.Fields = append(index.Fields, field)
+	}
+	return nil
+}
+
+// docstoreFieldName returns field name in docstore. It returns false if the field is ignored by "-".
+func docstoreFieldName(f reflect.StructField) (string, bool) {
+	tag, ok := f.Tag.Lookup("docstore")
+	if !ok {
+		return f.Name, true
+	}
+	if strings.HasPrefix(tag, "-") {
+		return "", false
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, true
+	}
+	return f.Name, true
+}
@@ -0,0 +1,179 @@
+package gocloudurls
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type IndexedDocument struct {
+	ID        string    `docstore:"id"`
+	Owner     string    `docstore:"owner" index:"by_owner"`
+	Status    string    `docstore:"status,omitempty" index:"by_owner;by_status"`
+	Tags      []string  `docstore:"tags" index:"by_tag,array"`
+	CreatedAt time.Time `docstore:"created_at" index:"by_owner,desc;by_tag,desc"`
+	Email     string    `index:"by_email,unique"`
+	Hash      string    `docstore:"-" index:"by_hash"`
+}
+
+func TestFirestoreSchema(t *testing.T) {
+	fs, err := NewFirestoreSchema(&IndexedDocument{}, "firestore://my-project/tasks?name_field=id")
+	assert.Nil(t, err)
+	if err == nil {
+		assert.Equal(t, "tasks", fs.Collection)
+		assert.Equal(t, "id", fs.NameField)
+		assert.Equal(t, []*DocStoreIndex{
+			{Name: "by_owner", Fields: []IndexField{{"owner", "asc"}, {"status", "asc"}, {"created_at", "desc"}}},
+			{Name: "by_status", Fields: []IndexField{{"status", "asc"}}},
+			{Name: "by_tag", Fields: []IndexField{{"tags", "array"}, {"created_at", "desc"}}},
+			{Name: "by_email", Fields: []IndexField{{"Email", "asc"}}, Unique: true},
+		}, fs.Indexes)
+		assert.Equal(t, `{
+  "indexes": [
+    {
+      "collectionGroup": "tasks",
+      "queryScope": "COLLECTION",
+      "fields": [
+        {
+          "fieldPath": "owner",
+          "order": "ASCENDING"
+        },
+        {
+          "fieldPath": "status",
+          "order": "ASCENDING"
+        },
+        {
+          "fieldPath": "created_at",
+          "order": "DESCENDING"
+        }
+      ]
+    },
+    {
+      "collectionGroup": "tasks",
+      "queryScope": "COLLECTION",
+      "fields": [
+        {
+          "fieldPath": "tags",
+          "arrayConfig": "CONTAINS"
+        },
+        {
+          "fieldPath": "created_at",
+          "order": "DESCENDING"
+        }
+      ]
+    }
+  ],
+  "fieldOverrides": []
+}`, fs.IndexesJSON())
+	}
+}
+
+func TestFirestoreSchemaWithNameField(t *testing.T) {
+	type Task struct {
+		ID     string `docstore:"id" index:"by_status,desc"`
+		Status string `docstore:"status" index:"by_status"`
+	}
+	fs, err := NewFirestoreSchema(&Task{}, "firestore://my-project/tasks?name_field=id")
+	assert.Nil(t, err)
+	if err == nil {
+		assert.Equal(t, `{
+  "indexes": [
+    {
+      "collectionGroup": "tasks",
+      "queryScope": "COLLECTION",
+      "fields": [
+        {
+          "fieldPath": "__name__",
+          "order": "DESCENDING"
+        },
+        {
+          "fieldPath": "status",
+          "order": "ASCENDING"
+        }
+      ]
+    }
+  ],
+  "fieldOverrides": []
+}`, fs.IndexesJSON())
+	}
+}
+
+func TestMongoSchema(t *testing.T) {
+	ms, err := NewMongoSchema(&IndexedDocument{}, "mongo://my-db/tasks?id_field=id")
+	assert.Nil(t, err)
+	if err == nil {
+		assert.Equal(t, "my-db", ms.Database)
+		assert.Equal(t, "tasks", ms.Collection)
+		assert.Equal(t, "id", ms.IDField)
+		assert.Equal(t, []string{
+			`db.getSiblingDB("my-db").getCollection("tasks").createIndex({"owner": 1, "status": 1, "created_at": -1}, {"name": "by_owner"})`,
+			`db.getSiblingDB("my-db").getCollection("tasks").createIndex({"status": 1}, {"name": "by_status"})`,
+			`db.getSiblingDB("my-db").getCollection("tasks").createIndex({"tags": 1, "created_at": -1}, {"name": "by_tag"})`,
+			`db.getSiblingDB("my-db").getCollection("tasks").createIndex({"Email": 1}, {"name": "by_email", "unique": true})`,
+		}, ms.CreateIndexCommands())
+	}
+}
+
+func TestMongoSchemaWithIDField(t *testing.T) {
+	type User struct {
+		ID     string `docstore:"id" index:"by_tenant"`
+		Email  string `docstore:"email" index:"by_email,unique"`
+		Tenant string `docstore:"tenant" index:"by_tenant"`
+	}
+	ms, err := NewMongoSchema(&User{}, "mongo://my-db/users?id_field=id")
+	assert.Nil(t, err)
+	if err == nil {
+		// id_field is stored as _id, so it is not indexed again
+		assert.Equal(t, []string{
+			`db.getSiblingDB("my-db").getCollection("users").createIndex({"_id": 1, "tenant": 1}, {"name": "by_tenant"})`,
+			`db.getSiblingDB("my-db").getCollection("users").createIndex({"email": 1}, {"name": "by_email", "unique": true})`,
+		}, ms.CreateIndexCommands())
+	}
+}
+
+func TestDocStoreSchemaWithStructValue(t *testing.T) {
+	fs, err := NewFirestoreSchema(IndexedDocument{}, "firestore://my-project/tasks?name_field=id")
+	assert.Nil(t, err)
+	if err == nil {
+		assert.Equal(t, 4, len(fs.Indexes))
+	}
+}
+
+func TestDocStoreSchemaWithInvalidTags(t *testing.T) {
+	type MultipleArrays struct {
+		ID     string   `docstore:"_id"`
+		Tags   []string `docstore:"tags" index:"by_tag,array"`
+		Labels []string `docstore:"labels" index:"by_tag,array"`
+	}
+	type UnknownOption struct {
+		ID     string `docstore:"_id"`
+		Status string `docstore:"status" index:"by_status,descending"`
+	}
+	type EmptyName struct {
+		ID     string `docstore:"_id"`
+		Status string `docstore:"status" index:",desc"`
+	}
+	type NoKey struct {
+		Status string `docstore:"status"`
+	}
+	testcases := []struct {
+		name   string
+		entity interface{}
+		url    string
+	}{
+		{name: "multiple array fields", entity: &MultipleArrays{}, url: "mongo://my-db/tasks"},
+		{name: "unknown option", entity: &UnknownOption{}, url: "mongo://my-db/tasks"},
+		{name: "empty index name", entity: &EmptyName{}, url: "mongo://my-db/tasks"},
+		{name: "no key field", entity: &NoKey{}, url: "mongo://my-db/tasks"},
+		{name: "wrong scheme", entity: &UnknownOption{}, url: "dynamodb://tasks"},
+		{name: "not struct", entity: "tasks", url: "mongo://my-db/tasks"},
+		{name: "nil pointer", entity: (*NoKey)(nil), url: "mongo://my-db/tasks"},
+		{name: "nil", entity: nil, url: "mongo://my-db/tasks"},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			_, err := NewMongoSchema(testcase.entity, testcase.url)
+			assert.NotNil(t, err)
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package gocloudurls
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -28,10 +30,31 @@ type Field struct {
 //	 // "--attribute-definitions", "AttributeName=name,AttributeType=S",
 //	 // "--key-schema", "AttributeName=name,KeyType=HASH",
 //	 // "--provisioned-throughput", "ReadCapacityUnits=5,WriteCapacityUnits=5",
+//
+// Secondary indexes are specified by ``dynamo`` tag. Hash key of local secondary index is the partition key of table.
+// Use ";" to specify multiple indexes for a field:
+//
+//   type Task struct {
+//       ID        string    `docstore:"id"`
+//       Owner     string    `docstore:"owner"`
+//       Status    string    `docstore:"status" dynamo:"gsi=by_status,hash"`
+//       CreatedAt time.Time `docstore:"created_at" dynamo:"gsi=by_status,range;lsi=by_created"`
+//   }
 type DynamoDBSchema struct {
-	Collection        string
-	PartitionKeyField *Field
-	SortKeyField      *Field
+	Collection             string
+	PartitionKeyField      *Field
+	SortKeyField           *Field
+	GlobalSecondaryIndexes []*Index
+	LocalSecondaryIndexes  []*Index
+}
+
+// Index is a secondary index of DynamoDB. Projection is "ALL" or "KEYS_ONLY" that is specified by dynamo tag
+// (ALL if it is empty).
+type Index struct {
+	Name          string
+	HashKeyField  *Field
+	RangeKeyField *Field
+	Projection    string
 }
 
 // CreateTableCommand returns command line to create table.
@@ -40,12 +63,7 @@ func (d DynamoDBSchema) CreateTableCommand(opt ...SchemaOption) []string {
 	if len(opt) > 0 {
 		o = opt[0]
 	}
-	if o.ReadCapacityUnits == 0 {
-		o.ReadCapacityUnits = 5
-	}
-	if o.WriteCapacityUnits == 0 {
-		o.WriteCapacityUnits = 5
-	}
+	o = o.withDefaults()
 	result := []string{
 		"aws",
 		"dynamodb",
@@ -53,30 +71,186 @@ func (d DynamoDBSchema) CreateTableCommand(opt ...SchemaOption) []string {
 		"--table-name",
 		d.Collection,
 	}
+	result = append(result, "--attribute-definitions")
+	for _, f := range d.attributes() {
+		result = append(result, fmt.Sprintf("AttributeName=%s,AttributeType=%s", f.Name, f.Type))
+	}
+	result = append(result,
+		"--key-schema",
+		fmt.Sprintf("AttributeName=%s,KeyType=HASH", d.PartitionKeyField.Name))
 	if d.SortKeyField != nil {
-		result = append(result,
-			"--attribute-definitions",
-			fmt.Sprintf("AttributeName=%s,AttributeType=%s", d.PartitionKeyField.Name, d.PartitionKeyField.Type),
-			fmt.Sprintf("AttributeName=%s,AttributeType=%s", d.SortKeyField.Name, d.SortKeyField.Type),
-			"--key-schema",
-			fmt.Sprintf("AttributeName=%s,KeyType=HASH", d.PartitionKeyField.Name),
-			fmt.Sprintf("AttributeName=%s,KeyType=RANGE", d.SortKeyField.Name))
+		result = append(result, fmt.Sprintf("AttributeName=%s,KeyType=RANGE", d.SortKeyField.Name))
+	}
+	throughput := &o
+	if o.BillingMode == "PAY_PER_REQUEST" {
+		result = append(result, "--billing-mode", o.BillingMode)
+		throughput = nil
 	} else {
 		result = append(result,
-			"--attribute-definitions",
-			fmt.Sprintf("AttributeName=%s,AttributeType=%s", d.PartitionKeyField.Name, d.PartitionKeyField.Type),
-			"--key-schema",
-			fmt.Sprintf("AttributeName=%s,KeyType=HASH", d.PartitionKeyField.Name))
+			"--provisioned-throughput",
+			fmt.Sprintf("ReadCapacityUnits=%d,WriteCapacityUnits=%d", o.ReadCapacityUnits, o.WriteCapacityUnits))
+	}
+	if len(d.GlobalSecondaryIndexes) > 0 {
+		result = append(result, "--global-secondary-indexes", indexesJSON(d.GlobalSecondaryIndexes, throughput))
+	}
+	if len(d.LocalSecondaryIndexes) > 0 {
+		result = append(result, "--local-secondary-indexes", indexesJSON(d.LocalSecondaryIndexes, nil))
+	}
+	if o.StreamViewType != "" {
+		result = append(result, "--stream-specification", "StreamEnabled=true,StreamViewType="+o.StreamViewType)
+	}
+	if o.KMSKeyID != "" {
+		result = append(result, "--sse-specification", "Enabled=true,SSEType=KMS,KMSMasterKeyId="+o.KMSKeyID)
+	} else if o.SSE {
+		result = append(result, "--sse-specification", "Enabled=true,SSEType=KMS")
+	}
+	if o.TableClass != "" {
+		result = append(result, "--table-class", o.TableClass)
+	}
+	if len(o.Tags) > 0 {
+		keys := make([]string, 0, len(o.Tags))
+		for key := range o.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		result = append(result, "--tags")
+		for _, key := range keys {
+			result = append(result, fmt.Sprintf("Key=%s,Value=%s", key, o.Tags[key]))
+		}
 	}
-	result = append(result,
-		"--provisioned-throughput",
-		fmt.Sprintf("ReadCapacityUnits=%d,WriteCapacityUnits=%d", o.ReadCapacityUnits, o.WriteCapacityUnits))
 	return result
 }
 
+// SetupCommands returns command lines to create table and to update settings that create-table doesn't support
+// (time to live and point-in-time recovery).
+func (d DynamoDBSchema) SetupCommands(opt ...SchemaOption) [][]string {
+	var o SchemaOption
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	result := [][]string{d.CreateTableCommand(o)}
+	if o.TTLAttribute != "" {
+		result = append(result, []string{
+			"aws", "dynamodb", "update-time-to-live", "--table-name", d.Collection,
+			"--time-to-live-specification", "Enabled=true,AttributeName=" + o.TTLAttribute,
+		})
+	}
+	if o.PointInTimeRecovery {
+		result = append(result, []string{
+			"aws", "dynamodb", "update-continuous-backups", "--table-name", d.Collection,
+			"--point-in-time-recovery-specification", "PointInTimeRecoveryEnabled=true",
+		})
+	}
+	return result
+}
+
+// attributes returns key fields of table and indexes without duplication.
+func (d DynamoDBSchema) attributes() []*Field {
+	var result []*Field
+	found := map[string]bool{}
+	add := func(f *Field) {
+		if f != nil && !found[f.Name] {
+			found[f.Name] = true
+			result = append(result, f)
+		}
+	}
+	add(d.PartitionKeyField)
+	add(d.SortKeyField)
+	for _, index := range d.GlobalSecondaryIndexes {
+		add(index.HashKeyField)
+		add(index.RangeKeyField)
+	}
+	for _, index := range d.LocalSecondaryIndexes {
+		add(index.RangeKeyField)
+	}
+	return result
+}
+
+type dynamoKeySchema struct {
+	AttributeName string `json:"AttributeName"`
+	KeyType       string `json:"KeyType"`
+}
+
+type dynamoProjection struct {
+	ProjectionType string `json:"ProjectionType"`
+}
+
+type dynamoThroughput struct {
+	ReadCapacityUnits  int `json:"ReadCapacityUnits"`
+	WriteCapacityUnits int `json:"WriteCapacityUnits"`
+}
+
+type dynamoIndex struct {
+	IndexName             string            `json:"IndexName"`
+	KeySchema             []dynamoKeySchema `json:"KeySchema"`
+	Projection            dynamoProjection  `json:"Projection"`
+	ProvisionedThroughput *dynamoThroughput `json:"ProvisionedThroughput,omitempty"`
+}
+
+// indexesJSON returns JSON of secondary indexes for AWS CLI. Local secondary index doesn't have throughput.
+func indexesJSON(indexes []*Index, o *SchemaOption) string {
+	b, _ := json.Marshal(buildIndexes(indexes, o))
+	return string(b)
+}
+
+func buildIndexes(indexes []*Index, o *SchemaOption) []dynamoIndex {
+	if len(indexes) == 0 {
+		return nil
+	}
+	result := make([]dynamoIndex, len(indexes))
+	for i, index := range indexes {
+		result[i] = dynamoIndex{
+			IndexName:  index.Name,
+			KeySchema:  []dynamoKeySchema{{AttributeName: index.HashKeyField.Name, KeyType: "HASH"}},
+			Projection: dynamoProjection{ProjectionType: projectionType(index)},
+		}
+		if index.RangeKeyField != nil {
+			result[i].KeySchema = append(result[i].KeySchema, dynamoKeySchema{AttributeName: index.RangeKeyField.Name, KeyType: "RANGE"})
+		}
+		if o != nil {
+			result[i].ProvisionedThroughput = &dynamoThroughput{
+				ReadCapacityUnits:  o.ReadCapacityUnits,
+				WriteCapacityUnits: o.WriteCapacityUnits,
+			}
+		}
+	}
+	return result
+}
+
+// SchemaOption is a option for CreateTableCommand and SetupCommands
+//
+// BillingMode is "PROVISIONED" (default) or "PAY_PER_REQUEST". ReadCapacityUnits and WriteCapacityUnits
+// (default 5) are used only for PROVISIONED.
+//
+// StreamViewType is "NEW_IMAGE", "OLD_IMAGE", "NEW_AND_OLD_IMAGES" or "KEYS_ONLY". Stream is enabled if it is set.
+//
+// If SSE is true or KMSKeyID is set, table is encrypted by KMS key (AWS managed key if KMSKeyID is empty).
+// KMSKeyID can be key ID, key ARN, alias name or alias ARN, but TerraformHCL accepts only key ARN (kms_key_arn).
+//
+// TableClass is "STANDARD" or "STANDARD_INFREQUENT_ACCESS".
+//
+// TTLAttribute and PointInTimeRecovery are set by follow-up commands of SetupCommands.
 type SchemaOption struct {
-	ReadCapacityUnits  int
-	WriteCapacityUnits int
+	ReadCapacityUnits   int
+	WriteCapacityUnits  int
+	BillingMode         string
+	StreamViewType      string
+	SSE                 bool
+	KMSKeyID            string
+	TableClass          string
+	Tags                map[string]string
+	TTLAttribute        string
+	PointInTimeRecovery bool
+}
+
+func (o SchemaOption) withDefaults() SchemaOption {
+	if o.ReadCapacityUnits == 0 {
+		o.ReadCapacityUnits = 5
+	}
+	if o.WriteCapacityUnits == 0 {
+		o.WriteCapacityUnits = 5
+	}
+	return o
 }
 
 // NewDynamoDBSchema creates DynamoDBSchema from urlString(it should be a return of NormalizeDocStoreURL),
@@ -107,14 +281,14 @@ func NewDynamoDBSchema(collectionEntity interface{}, urlString string) (*DynamoD
 
 	for i := 0; i < sv.Type().NumField(); i++ {
 		f := sv.Type().Field(i)
-		var fieldName string
-		if tag, ok := f.Tag.Lookup("docstore"); ok {
-			if strings.HasPrefix(tag, "-") {
-				continue
+		fieldName, ok := docstoreFieldName(f)
+		if !ok {
+			continue
+		}
+		if tag, ok := f.Tag.Lookup("dynamo"); ok {
+			if err := result.addIndexes(fieldName, f.Type, tag); err != nil {
+				return nil, err
 			}
-			fieldName = strings.Split(tag, ",")[0]
-		} else {
-			fieldName = f.Name
 		}
 		if partitionKey == fieldName {
 			t, err := detectDynamoType(f.Type)
@@ -136,9 +310,102 @@ func NewDynamoDBSchema(collectionEntity interface{}, urlString string) (*DynamoD
 			}
 		}
 	}
+	if result.PartitionKeyField == nil {
+		return nil, fmt.Errorf("partition key field '%s' is not in struct %s", partitionKey, sv.Type().String())
+	}
+	if sortKey != "" && result.SortKeyField == nil {
+		return nil, fmt.Errorf("sort key field '%s' is not in struct %s", sortKey, sv.Type().String())
+	}
+	for _, index := range result.GlobalSecondaryIndexes {
+		if index.HashKeyField == nil {
+			return nil, fmt.Errorf("global secondary index '%s' doesn't have hash key", index.Name)
+		}
+	}
+	for _, index := range result.LocalSecondaryIndexes {
+		if result.PartitionKeyField == nil || result.SortKeyField == nil {
+			return nil, fmt.Errorf("local secondary index '%s' requires sort key of table", index.Name)
+		}
+		index.HashKeyField = result.PartitionKeyField
+	}
 	return result, nil
 }
 
+// addIndexes parses dynamo tag like "gsi=by_status,hash;lsi=by_created" and adds the field to indexes.
+// Projection of index can be added after key type like "gsi=by_status,hash,keys_only" or "lsi=by_created,all".
+func (d *DynamoDBSchema) addIndexes(fieldName string, fieldType reflect.Type, tag string) error {
+	t, err := detectDynamoType(fieldType)
+	if err != nil || t == "-" || t == "BOOL" {
+		return fmt.Errorf("This type %s is not supported for dynamo index key", fieldType.String())
+	}
+	field := &Field{Name: fieldName, Type: t}
+	for _, spec := range strings.Split(tag, ";") {
+		elements := strings.Split(spec, ",")
+		keyValue := strings.SplitN(elements[0], "=", 2)
+		if len(keyValue) != 2 || keyValue[1] == "" || len(elements) > 3 {
+			return fmt.Errorf("dynamo tag should be gsi=(index),hash, gsi=(index),range or lsi=(index) (with projection), but '%s'", spec)
+		}
+		keyType := "range"
+		var projection string
+		for i, option := range elements[1:] {
+			switch option {
+			case "all":
+				projection = "ALL"
+			case "keys_only":
+				projection = "KEYS_ONLY"
+			default:
+				if i > 0 {
+					return fmt.Errorf("projection of index should be all or keys_only, but '%s'", option)
+				}
+				keyType = option
+			}
+		}
+		var index *Index
+		switch keyValue[0] {
+		case "gsi":
+			index = findIndex(&d.GlobalSecondaryIndexes, keyValue[1])
+		case "lsi":
+			if keyType != "range" {
+				return fmt.Errorf("key of local secondary index '%s' should be range key", keyValue[1])
+			}
+			index = findIndex(&d.LocalSecondaryIndexes, keyValue[1])
+		default:
+			return fmt.Errorf("dynamo tag should be gsi=(index),hash, gsi=(index),range or lsi=(index), but '%s'", spec)
+		}
+		if projection != "" {
+			if index.Projection != "" && index.Projection != projection {
+				return fmt.Errorf("index '%s' has multiple projections", index.Name)
+			}
+			index.Projection = projection
+		}
+		switch keyType {
+		case "hash":
+			if index.HashKeyField != nil {
+				return fmt.Errorf("index '%s' has multiple hash keys", index.Name)
+			}
+			index.HashKeyField = field
+		case "range":
+			if index.RangeKeyField != nil {
+				return fmt.Errorf("index '%s' has multiple range keys", index.Name)
+			}
+			index.RangeKeyField = field
+		default:
+			return fmt.Errorf("key type of index should be hash or range, but '%s'", keyType)
+		}
+	}
+	return nil
+}
+
+func findIndex(indexes *[]*Index, name string) *Index {
+	for _, index := range *indexes {
+		if index.Name == name {
+			return index
+		}
+	}
+	index := &Index{Name: name}
+	*indexes = append(*indexes, index)
+	return index
+}
+
 func detectDynamoType(t reflect.Type) (string, error) {
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
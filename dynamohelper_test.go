@@ -36,6 +36,23 @@ func TestDocStoreSchema(t *testing.T) {
 	}
 }
 
+func TestDocStoreSchemaWithEmptyTagName(t *testing.T) {
+	// docstore uses Go field name if the name part of tag is empty
+	type Task struct {
+		Owner  string `docstore:",omitempty"`
+		Status string `docstore:",omitempty" dynamo:"gsi=by_status,hash"`
+	}
+	ds, err := NewDynamoDBSchema(&Task{}, "dynamodb://tasks?partition_key=Owner")
+	assert.Nil(t, err)
+	if err == nil {
+		assert.Equal(t, &Field{Name: "Owner", Type: "S"}, ds.PartitionKeyField)
+		assert.Equal(t, 1, len(ds.GlobalSecondaryIndexes))
+		if len(ds.GlobalSecondaryIndexes) == 1 {
+			assert.Equal(t, "Status", ds.GlobalSecondaryIndexes[0].HashKeyField.Name)
+		}
+	}
+}
+
 func Test_detectDynamoType(t *testing.T) {
 	type args struct {
 		t reflect.Type
@@ -96,3 +113,178 @@ func Test_detectDynamoType(t *testing.T) {
 		})
 	}
 }
+
+type IndexedTask struct {
+	Owner     string    `docstore:"owner"`
+	ID        string    `docstore:"id"`
+	Status    string    `docstore:"status" dynamo:"gsi=by_status,hash"`
+	CreatedAt time.Time `docstore:"created_at" dynamo:"gsi=by_status,range;lsi=by_created"`
+	Priority  int       `dynamo:"gsi=by_priority,hash,keys_only"`
+}
+
+func TestDocStoreSchemaWithIndexes(t *testing.T) {
+	ds, err := NewDynamoDBSchema(&IndexedTask{}, "dynamodb://tasks?partition_key=owner&sort_key=id")
+	assert.Nil(t, err)
+	if err == nil {
+		assert.Equal(t, []string{
+			"aws", "dynamodb", "create-table", "--table-name", "tasks",
+			"--attribute-definitions",
+			"AttributeName=owner,AttributeType=S",
+			"AttributeName=id,AttributeType=S",
+			"AttributeName=status,AttributeType=S",
+			"AttributeName=created_at,AttributeType=S",
+			"AttributeName=Priority,AttributeType=N",
+			"--key-schema", "AttributeName=owner,KeyType=HASH", "AttributeName=id,KeyType=RANGE",
+			"--provisioned-throughput", "ReadCapacityUnits=10,WriteCapacityUnits=5",
+			"--global-secondary-indexes",
+			`[{"IndexName":"by_status","KeySchema":[{"AttributeName":"status","KeyType":"HASH"},{"AttributeName":"created_at","KeyType":"RANGE"}],"Projection":{"ProjectionType":"ALL"},"ProvisionedThroughput":{"ReadCapacityUnits":10,"WriteCapacityUnits":5}},` +
+				`{"IndexName":"by_priority","KeySchema":[{"AttributeName":"Priority","KeyType":"HASH"}],"Projection":{"ProjectionType":"KEYS_ONLY"},"ProvisionedThroughput":{"ReadCapacityUnits":10,"WriteCapacityUnits":5}}]`,
+			"--local-secondary-indexes",
+			`[{"IndexName":"by_created","KeySchema":[{"AttributeName":"owner","KeyType":"HASH"},{"AttributeName":"created_at","KeyType":"RANGE"}],"Projection":{"ProjectionType":"ALL"}}]`,
+		}, ds.CreateTableCommand(SchemaOption{ReadCapacityUnits: 10}))
+	}
+}
+
+func TestDocStoreSchemaWithInvalidIndexes(t *testing.T) {
+	testcases := []struct {
+		name   string
+		entity interface{}
+		url    string
+	}{
+		{
+			name: "gsi without hash key",
+			entity: &struct {
+				ID     string `docstore:"id"`
+				Status string `dynamo:"gsi=by_status,range"`
+			}{},
+			url: "dynamodb://tasks?partition_key=id",
+		},
+		{
+			name: "lsi without sort key of table",
+			entity: &struct {
+				ID     string `docstore:"id"`
+				Status string `dynamo:"lsi=by_status"`
+			}{},
+			url: "dynamodb://tasks?partition_key=id",
+		},
+		{
+			name: "multiple hash keys",
+			entity: &struct {
+				ID     string `docstore:"id"`
+				Status string `dynamo:"gsi=by_status,hash"`
+				Owner  string `dynamo:"gsi=by_status,hash"`
+			}{},
+			url: "dynamodb://tasks?partition_key=id",
+		},
+		{
+			name: "unknown index type",
+			entity: &struct {
+				ID     string `docstore:"id"`
+				Status string `dynamo:"index=by_status,hash"`
+			}{},
+			url: "dynamodb://tasks?partition_key=id",
+		},
+		{
+			name: "unknown projection",
+			entity: &struct {
+				ID     string `docstore:"id"`
+				Status string `dynamo:"gsi=by_status,hash,include"`
+			}{},
+			url: "dynamodb://tasks?partition_key=id",
+		},
+		{
+			name: "multiple projections",
+			entity: &struct {
+				ID        string `docstore:"id"`
+				Status    string `dynamo:"gsi=by_status,hash,keys_only"`
+				CreatedAt string `dynamo:"gsi=by_status,range,all"`
+			}{},
+			url: "dynamodb://tasks?partition_key=id",
+		},
+		{
+			name: "no partition key field",
+			entity: &struct {
+				ID string `docstore:"id"`
+			}{},
+			url: "dynamodb://tasks",
+		},
+		{
+			name: "no sort key field",
+			entity: &struct {
+				ID string `docstore:"id"`
+			}{},
+			url: "dynamodb://tasks?partition_key=id&sort_key=created_at",
+		},
+		{
+			name: "unsupported key type",
+			entity: &struct {
+				ID   string `docstore:"id"`
+				Done bool   `dynamo:"gsi=by_done,hash"`
+			}{},
+			url: "dynamodb://tasks?partition_key=id",
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			_, err := NewDynamoDBSchema(testcase.entity, testcase.url)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestCreateTableCommandWithSchemaOption(t *testing.T) {
+	ds, err := NewDynamoDBSchema(&IndexedTask{}, "dynamodb://tasks?partition_key=owner&sort_key=id")
+	assert.Nil(t, err)
+	opt := SchemaOption{
+		BillingMode:         "PAY_PER_REQUEST",
+		StreamViewType:      "NEW_AND_OLD_IMAGES",
+		KMSKeyID:            "alias/my-key",
+		TableClass:          "STANDARD_INFREQUENT_ACCESS",
+		Tags:                map[string]string{"team": "backend", "env": "prod"},
+		TTLAttribute:        "expires_at",
+		PointInTimeRecovery: true,
+	}
+	commands := ds.SetupCommands(opt)
+	assert.Equal(t, 3, len(commands))
+	assert.Equal(t, []string{
+		"aws", "dynamodb", "create-table", "--table-name", "tasks",
+		"--attribute-definitions",
+		"AttributeName=owner,AttributeType=S",
+		"AttributeName=id,AttributeType=S",
+		"AttributeName=status,AttributeType=S",
+		"AttributeName=created_at,AttributeType=S",
+		"AttributeName=Priority,AttributeType=N",
+		"--key-schema", "AttributeName=owner,KeyType=HASH", "AttributeName=id,KeyType=RANGE",
+		"--billing-mode", "PAY_PER_REQUEST",
+		"--global-secondary-indexes",
+		`[{"IndexName":"by_status","KeySchema":[{"AttributeName":"status","KeyType":"HASH"},{"AttributeName":"created_at","KeyType":"RANGE"}],"Projection":{"ProjectionType":"ALL"}},` +
+			`{"IndexName":"by_priority","KeySchema":[{"AttributeName":"Priority","KeyType":"HASH"}],"Projection":{"ProjectionType":"KEYS_ONLY"}}]`,
+		"--local-secondary-indexes",
+		`[{"IndexName":"by_created","KeySchema":[{"AttributeName":"owner","KeyType":"HASH"},{"AttributeName":"created_at","KeyType":"RANGE"}],"Projection":{"ProjectionType":"ALL"}}]`,
+		"--stream-specification", "StreamEnabled=true,StreamViewType=NEW_AND_OLD_IMAGES",
+		"--sse-specification", "Enabled=true,SSEType=KMS,KMSMasterKeyId=alias/my-key",
+		"--table-class", "STANDARD_INFREQUENT_ACCESS",
+		"--tags", "Key=env,Value=prod", "Key=team,Value=backend",
+	}, commands[0])
+	assert.Equal(t, []string{
+		"aws", "dynamodb", "update-time-to-live", "--table-name", "tasks",
+		"--time-to-live-specification", "Enabled=true,AttributeName=expires_at",
+	}, commands[1])
+	assert.Equal(t, []string{
+		"aws", "dynamodb", "update-continuous-backups", "--table-name", "tasks",
+		"--point-in-time-recovery-specification", "PointInTimeRecoveryEnabled=true",
+	}, commands[2])
+}
+
+func TestSetupCommandsWithDefaultOption(t *testing.T) {
+	ds, err := NewDynamoDBSchema(&TestStruct{}, "dynamodb://tasks?partition_key=name")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{ds.CreateTableCommand()}, ds.SetupCommands())
+	assert.Equal(t, []string{
+		"aws", "dynamodb", "create-table", "--table-name", "tasks",
+		"--attribute-definitions", "AttributeName=name,AttributeType=S",
+		"--key-schema", "AttributeName=name,KeyType=HASH",
+		"--provisioned-throughput", "ReadCapacityUnits=5,WriteCapacityUnits=5",
+		"--sse-specification", "Enabled=true,SSEType=KMS",
+	}, ds.CreateTableCommand(SchemaOption{SSE: true}))
+}
@@ -0,0 +1,379 @@
+package gocloudurls
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type dynamoAttribute struct {
+	AttributeName string `json:"AttributeName"`
+	AttributeType string `json:"AttributeType"`
+}
+
+type dynamoTag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+type dynamoStream struct {
+	StreamEnabled  bool   `json:"StreamEnabled,omitempty"`
+	StreamViewType string `json:"StreamViewType"`
+}
+
+type dynamoSSE struct {
+	Enabled        bool   `json:"Enabled,omitempty"`
+	SSEEnabled     bool   `json:"SSEEnabled,omitempty"`
+	SSEType        string `json:"SSEType"`
+	KMSMasterKeyID string `json:"KMSMasterKeyId,omitempty"`
+}
+
+type dynamoTTL struct {
+	AttributeName string `json:"AttributeName"`
+	Enabled       bool   `json:"Enabled"`
+}
+
+type dynamoPITR struct {
+	PointInTimeRecoveryEnabled bool `json:"PointInTimeRecoveryEnabled"`
+}
+
+// dynamoTable is a request of CreateTable API and properties of AWS::DynamoDB::Table.
+// TimeToLiveSpecification and PointInTimeRecoverySpecification are only for CloudFormation.
+type dynamoTable struct {
+	TableName                        string            `json:"TableName"`
+	AttributeDefinitions             []dynamoAttribute `json:"AttributeDefinitions"`
+	KeySchema                        []dynamoKeySchema `json:"KeySchema"`
+	BillingMode                      string            `json:"BillingMode,omitempty"`
+	ProvisionedThroughput            *dynamoThroughput `json:"ProvisionedThroughput,omitempty"`
+	GlobalSecondaryIndexes           []dynamoIndex     `json:"GlobalSecondaryIndexes,omitempty"`
+	LocalSecondaryIndexes            []dynamoIndex     `json:"LocalSecondaryIndexes,omitempty"`
+	StreamSpecification              *dynamoStream     `json:"StreamSpecification,omitempty"`
+	SSESpecification                 *dynamoSSE        `json:"SSESpecification,omitempty"`
+	TableClass                       string            `json:"TableClass,omitempty"`
+	Tags                             []dynamoTag       `json:"Tags,omitempty"`
+	TimeToLiveSpecification          *dynamoTTL        `json:"TimeToLiveSpecification,omitempty"`
+	PointInTimeRecoverySpecification *dynamoPITR       `json:"PointInTimeRecoverySpecification,omitempty"`
+}
+
+func (d DynamoDBSchema) table(o SchemaOption, cloudFormation bool) dynamoTable {
+	o = o.withDefaults()
+	result := dynamoTable{
+		TableName: d.Collection,
+		KeySchema: []dynamoKeySchema{{AttributeName: d.PartitionKeyField.Name, KeyType: "HASH"}},
+	}
+	for _, f := range d.attributes() {
+		result.AttributeDefinitions = append(result.AttributeDefinitions, dynamoAttribute{AttributeName: f.Name, AttributeType: f.Type})
+	}
+	if d.SortKeyField != nil {
+		result.KeySchema = append(result.KeySchema, dynamoKeySchema{AttributeName: d.SortKeyField.Name, KeyType: "RANGE"})
+	}
+	throughput := &o
+	if o.BillingMode == "PAY_PER_REQUEST" {
+		result.BillingMode = o.BillingMode
+		throughput = nil
+	} else {
+		result.ProvisionedThroughput = &dynamoThroughput{ReadCapacityUnits: o.ReadCapacityUnits, WriteCapacityUnits: o.WriteCapacityUnits}
+	}
+	result.GlobalSecondaryIndexes = buildIndexes(d.GlobalSecondaryIndexes, throughput)
+	result.LocalSecondaryIndexes = buildIndexes(d.LocalSecondaryIndexes, nil)
+	if o.StreamViewType != "" {
+		result.StreamSpecification = &dynamoStream{StreamEnabled: !cloudFormation, StreamViewType: o.StreamViewType}
+	}
+	if o.SSE || o.KMSKeyID != "" {
+		result.SSESpecification = &dynamoSSE{
+			Enabled:        !cloudFormation,
+			SSEEnabled:     cloudFormation,
+			SSEType:        "KMS",
+			KMSMasterKeyID: o.KMSKeyID,
+		}
+	}
+	result.TableClass = o.TableClass
+	for _, key := range sortedKeys(o.Tags) {
+		result.Tags = append(result.Tags, dynamoTag{Key: key, Value: o.Tags[key]})
+	}
+	if cloudFormation {
+		if o.TTLAttribute != "" {
+			result.TimeToLiveSpecification = &dynamoTTL{AttributeName: o.TTLAttribute, Enabled: true}
+		}
+		if o.PointInTimeRecovery {
+			result.PointInTimeRecoverySpecification = &dynamoPITR{PointInTimeRecoveryEnabled: true}
+		}
+	}
+	return result
+}
+
+// CreateTableJSON returns request JSON of CreateTable API. It is good for DynamoDB Local in integration tests.
+//
+// Time to live and point-in-time recovery are not in the request. They need UpdateTimeToLive and
+// UpdateContinuousBackups API.
+func (d DynamoDBSchema) CreateTableJSON(opt ...SchemaOption) string {
+	var o SchemaOption
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	b, _ := json.MarshalIndent(d.table(o, false), "", "  ")
+	return string(b)
+}
+
+// cloudFormationResource returns AWS::DynamoDB::Table resource with logical ID.
+func (d DynamoDBSchema) cloudFormationResource(o SchemaOption) []byte {
+	var resource struct {
+		Type       string      `json:"Type"`
+		Properties dynamoTable `json:"Properties"`
+	}
+	resource.Type = "AWS::DynamoDB::Table"
+	resource.Properties = d.table(o, true)
+	b, _ := json.Marshal(resource)
+	// keep the order of properties: json.Marshal sorts keys of map
+	return []byte(fmt.Sprintf(`{%s:%s}`, quoteJSON(d.cloudFormationLogicalID()), b))
+}
+
+// CloudFormationJSON returns AWS::DynamoDB::Table resource of CloudFormation template as JSON.
+// It can be put in Resources section. Logical ID is made from table name ("tasks" → "TasksTable").
+func (d DynamoDBSchema) CloudFormationJSON(opt ...SchemaOption) string {
+	var o SchemaOption
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	var buffer bytes.Buffer
+	json.Indent(&buffer, d.cloudFormationResource(o), "", "  ")
+	return buffer.String()
+}
+
+// CloudFormationYAML returns AWS::DynamoDB::Table resource of CloudFormation template as YAML.
+func (d DynamoDBSchema) CloudFormationYAML(opt ...SchemaOption) string {
+	var o SchemaOption
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	dec := json.NewDecoder(bytes.NewReader(d.cloudFormationResource(o)))
+	dec.UseNumber()
+	value, _ := decodeOrdered(dec)
+	result, _ := yaml.Marshal(value)
+	return string(result)
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+var kmsKeyARN = regexp.MustCompile(`^arn:[^:]+:kms:[^:]+:[0-9]+:key/.+$`)
+
+func (d DynamoDBSchema) cloudFormationLogicalID() string {
+	var result string
+	for _, word := range nonAlphanumeric.Split(d.Collection, -1) {
+		if word != "" {
+			result += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return result + "Table"
+}
+
+// TerraformHCL returns aws_dynamodb_table resource block of Terraform.
+// Resource name is made from table name ("my-tasks" → "my_tasks").
+// SchemaOption.KMSKeyID is used as kms_key_arn, so it returns error if it is not key ARN
+// (arn:aws:kms:(region):(account):key/(key-id)).
+func (d DynamoDBSchema) TerraformHCL(opt ...SchemaOption) (string, error) {
+	var o SchemaOption
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	o = o.withDefaults()
+	if o.KMSKeyID != "" && !kmsKeyARN.MatchString(o.KMSKeyID) {
+		return "", fmt.Errorf("KMS key of Terraform should be key ARN like arn:aws:kms:(region):(account):key/(key-id), but '%s'", o.KMSKeyID)
+	}
+	name := nonAlphanumeric.ReplaceAllString(d.Collection, "_")
+	root := &hclBlock{header: fmt.Sprintf(`resource "aws_dynamodb_table" %s`, quoteJSON(name))}
+	root.attr("name", quoteJSON(d.Collection))
+	payPerRequest := o.BillingMode == "PAY_PER_REQUEST"
+	if payPerRequest {
+		root.attr("billing_mode", quoteJSON(o.BillingMode))
+	} else {
+		root.attr("billing_mode", quoteJSON("PROVISIONED"))
+		root.attr("read_capacity", fmt.Sprint(o.ReadCapacityUnits))
+		root.attr("write_capacity", fmt.Sprint(o.WriteCapacityUnits))
+	}
+	root.attr("hash_key", quoteJSON(d.PartitionKeyField.Name))
+	if d.SortKeyField != nil {
+		root.attr("range_key", quoteJSON(d.SortKeyField.Name))
+	}
+	if o.TableClass != "" {
+		root.attr("table_class", quoteJSON(o.TableClass))
+	}
+	if o.StreamViewType != "" {
+		root.attr("stream_enabled", "true")
+		root.attr("stream_view_type", quoteJSON(o.StreamViewType))
+	}
+	for _, f := range d.attributes() {
+		attribute := root.block("attribute")
+		attribute.attr("name", quoteJSON(f.Name))
+		attribute.attr("type", quoteJSON(f.Type))
+	}
+	for _, index := range d.GlobalSecondaryIndexes {
+		gsi := root.block("global_secondary_index")
+		gsi.attr("name", quoteJSON(index.Name))
+		gsi.attr("hash_key", quoteJSON(index.HashKeyField.Name))
+		if index.RangeKeyField != nil {
+			gsi.attr("range_key", quoteJSON(index.RangeKeyField.Name))
+		}
+		gsi.attr("projection_type", quoteJSON(projectionType(index)))
+		if !payPerRequest {
+			gsi.attr("read_capacity", fmt.Sprint(o.ReadCapacityUnits))
+			gsi.attr("write_capacity", fmt.Sprint(o.WriteCapacityUnits))
+		}
+	}
+	for _, index := range d.LocalSecondaryIndexes {
+		lsi := root.block("local_secondary_index")
+		lsi.attr("name", quoteJSON(index.Name))
+		lsi.attr("range_key", quoteJSON(index.RangeKeyField.Name))
+		lsi.attr("projection_type", quoteJSON(projectionType(index)))
+	}
+	if o.SSE || o.KMSKeyID != "" {
+		sse := root.block("server_side_encryption")
+		sse.attr("enabled", "true")
+		if o.KMSKeyID != "" {
+			sse.attr("kms_key_arn", quoteJSON(o.KMSKeyID))
+		}
+	}
+	if o.TTLAttribute != "" {
+		ttl := root.block("ttl")
+		ttl.attr("attribute_name", quoteJSON(o.TTLAttribute))
+		ttl.attr("enabled", "true")
+	}
+	if o.PointInTimeRecovery {
+		root.block("point_in_time_recovery").attr("enabled", "true")
+	}
+	if len(o.Tags) > 0 {
+		tags := root.block("tags =")
+		for _, key := range sortedKeys(o.Tags) {
+			tags.attr(quoteJSON(key), quoteJSON(o.Tags[key]))
+		}
+	}
+	var buffer bytes.Buffer
+	root.write(&buffer, 0)
+	return buffer.String(), nil
+}
+
+func projectionType(index *Index) string {
+	if index.Projection == "" {
+		return "ALL"
+	}
+	return index.Projection
+}
+
+// hclBlock is a block of HCL. Attributes are aligned by "=" like terraform fmt.
+type hclBlock struct {
+	header string
+	lines  []interface{}
+}
+
+type hclAttribute struct {
+	name  string
+	value string
+}
+
+func (b *hclBlock) attr(name, value string) {
+	b.lines = append(b.lines, hclAttribute{name: name, value: value})
+}
+
+func (b *hclBlock) block(header string) *hclBlock {
+	child := &hclBlock{header: header}
+	b.lines = append(b.lines, child)
+	return child
+}
+
+func (b *hclBlock) write(buffer *bytes.Buffer, indent int) {
+	prefix := strings.Repeat("  ", indent)
+	fmt.Fprintf(buffer, "%s%s {\n", prefix, b.header)
+	for i := 0; i < len(b.lines); {
+		if child, ok := b.lines[i].(*hclBlock); ok {
+			if i > 0 {
+				buffer.WriteString("\n")
+			}
+			child.write(buffer, indent+1)
+			i++
+			continue
+		}
+		// consecutive attributes are aligned
+		j := i
+		width := 0
+		for ; j < len(b.lines); j++ {
+			attr, ok := b.lines[j].(hclAttribute)
+			if !ok {
+				break
+			}
+			if len(attr.name) > width {
+				width = len(attr.name)
+			}
+		}
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		for _, line := range b.lines[i:j] {
+			attr := line.(hclAttribute)
+			fmt.Fprintf(buffer, "%s  %-*s = %s\n", prefix, width, attr.name, attr.value)
+		}
+		i = j
+	}
+	fmt.Fprintf(buffer, "%s}\n", prefix)
+}
+
+func quoteJSON(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// decodeOrdered decodes JSON value as yaml.MapSlice to keep the order of object keys.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := token.(type) {
+	case json.Delim:
+		if v == '{' {
+			result := yaml.MapSlice{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, yaml.MapItem{Key: key, Value: value})
+			}
+			_, err := dec.Token()
+			return result, err
+		}
+		result := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		_, err := dec.Token()
+		return result, err
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	}
+	return token, nil
+}
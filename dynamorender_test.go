@@ -0,0 +1,254 @@
+package gocloudurls
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type RenderedTask struct {
+	Owner     string `docstore:"owner"`
+	ID        string `docstore:"id"`
+	Status    string `docstore:"status" dynamo:"gsi=by_status,hash"`
+	CreatedAt int64  `docstore:"created_at" dynamo:"lsi=by_created"`
+}
+
+var renderOption = SchemaOption{
+	StreamViewType:      "NEW_IMAGE",
+	KMSKeyID:            "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+	Tags:                map[string]string{"team": "backend", "env": "prod"},
+	TTLAttribute:        "expires_at",
+	PointInTimeRecovery: true,
+}
+
+func TestCloudFormationYAML(t *testing.T) {
+	ds, err := NewDynamoDBSchema(&RenderedTask{}, "dynamodb://my-tasks?partition_key=owner&sort_key=id")
+	assert.Nil(t, err)
+	if err == nil {
+		assert.Equal(t, `MyTasksTable:
+  Type: AWS::DynamoDB::Table
+  Properties:
+    TableName: my-tasks
+    AttributeDefinitions:
+    - AttributeName: owner
+      AttributeType: S
+    - AttributeName: id
+      AttributeType: S
+    - AttributeName: status
+      AttributeType: S
+    - AttributeName: created_at
+      AttributeType: "N"
+    KeySchema:
+    - AttributeName: owner
+      KeyType: HASH
+    - AttributeName: id
+      KeyType: RANGE
+    ProvisionedThroughput:
+      ReadCapacityUnits: 5
+      WriteCapacityUnits: 5
+    GlobalSecondaryIndexes:
+    - IndexName: by_status
+      KeySchema:
+      - AttributeName: status
+        KeyType: HASH
+      Projection:
+        ProjectionType: ALL
+      ProvisionedThroughput:
+        ReadCapacityUnits: 5
+        WriteCapacityUnits: 5
+    LocalSecondaryIndexes:
+    - IndexName: by_created
+      KeySchema:
+      - AttributeName: owner
+        KeyType: HASH
+      - AttributeName: created_at
+        KeyType: RANGE
+      Projection:
+        ProjectionType: ALL
+    StreamSpecification:
+      StreamViewType: NEW_IMAGE
+    SSESpecification:
+      SSEEnabled: true
+      SSEType: KMS
+      KMSMasterKeyId: arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab
+    Tags:
+    - Key: env
+      Value: prod
+    - Key: team
+      Value: backend
+    TimeToLiveSpecification:
+      AttributeName: expires_at
+      Enabled: true
+    PointInTimeRecoverySpecification:
+      PointInTimeRecoveryEnabled: true
+`, ds.CloudFormationYAML(renderOption))
+	}
+}
+
+func TestCloudFormationJSON(t *testing.T) {
+	ds, err := NewDynamoDBSchema(&TestStruct{}, "dynamodb://tasks?partition_key=name")
+	assert.Nil(t, err)
+	if err == nil {
+		assert.Equal(t, `{
+  "TasksTable": {
+    "Type": "AWS::DynamoDB::Table",
+    "Properties": {
+      "TableName": "tasks",
+      "AttributeDefinitions": [
+        {
+          "AttributeName": "name",
+          "AttributeType": "S"
+        }
+      ],
+      "KeySchema": [
+        {
+          "AttributeName": "name",
+          "KeyType": "HASH"
+        }
+      ],
+      "BillingMode": "PAY_PER_REQUEST"
+    }
+  }
+}`, ds.CloudFormationJSON(SchemaOption{BillingMode: "PAY_PER_REQUEST"}))
+	}
+}
+
+func TestTerraformHCL(t *testing.T) {
+	ds, err := NewDynamoDBSchema(&RenderedTask{}, "dynamodb://my-tasks?partition_key=owner&sort_key=id")
+	assert.Nil(t, err)
+	if err == nil {
+		hcl, err := ds.TerraformHCL(renderOption)
+		assert.Nil(t, err)
+		assert.Equal(t, `resource "aws_dynamodb_table" "my_tasks" {
+  name             = "my-tasks"
+  billing_mode     = "PROVISIONED"
+  read_capacity    = 5
+  write_capacity   = 5
+  hash_key         = "owner"
+  range_key        = "id"
+  stream_enabled   = true
+  stream_view_type = "NEW_IMAGE"
+
+  attribute {
+    name = "owner"
+    type = "S"
+  }
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  attribute {
+    name = "status"
+    type = "S"
+  }
+
+  attribute {
+    name = "created_at"
+    type = "N"
+  }
+
+  global_secondary_index {
+    name            = "by_status"
+    hash_key        = "status"
+    projection_type = "ALL"
+    read_capacity   = 5
+    write_capacity  = 5
+  }
+
+  local_secondary_index {
+    name            = "by_created"
+    range_key       = "created_at"
+    projection_type = "ALL"
+  }
+
+  server_side_encryption {
+    enabled     = true
+    kms_key_arn = "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+  }
+
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  tags = {
+    "env"  = "prod"
+    "team" = "backend"
+  }
+}
+`, hcl)
+
+		hcl, err = ds.TerraformHCL(SchemaOption{BillingMode: "PAY_PER_REQUEST", TableClass: "STANDARD_INFREQUENT_ACCESS"})
+		assert.Nil(t, err)
+		assert.Equal(t, `resource "aws_dynamodb_table" "my_tasks" {
+  name         = "my-tasks"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "owner"
+  range_key    = "id"
+  table_class  = "STANDARD_INFREQUENT_ACCESS"
+
+  attribute {
+    name = "owner"
+    type = "S"
+  }
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  attribute {
+    name = "status"
+    type = "S"
+  }
+
+  attribute {
+    name = "created_at"
+    type = "N"
+  }
+
+  global_secondary_index {
+    name            = "by_status"
+    hash_key        = "status"
+    projection_type = "ALL"
+  }
+
+  local_secondary_index {
+    name            = "by_created"
+    range_key       = "created_at"
+    projection_type = "ALL"
+  }
+}
+`, hcl)
+
+		_, err = ds.TerraformHCL(SchemaOption{KMSKeyID: "alias/my-key"})
+		assert.NotNil(t, err)
+	}
+}
+
+func TestCreateTableJSON(t *testing.T) {
+	ds, err := NewDynamoDBSchema(&RenderedTask{}, "dynamodb://my-tasks?partition_key=owner&sort_key=id")
+	assert.Nil(t, err)
+	if err == nil {
+		var input map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(ds.CreateTableJSON(renderOption)), &input))
+		assert.Equal(t, "my-tasks", input["TableName"])
+		assert.Equal(t, 4, len(input["AttributeDefinitions"].([]interface{})))
+		assert.Equal(t, map[string]interface{}{"ReadCapacityUnits": 5.0, "WriteCapacityUnits": 5.0}, input["ProvisionedThroughput"])
+		assert.Equal(t, map[string]interface{}{"StreamEnabled": true, "StreamViewType": "NEW_IMAGE"}, input["StreamSpecification"])
+		assert.Equal(t, map[string]interface{}{"Enabled": true, "SSEType": "KMS", "KMSMasterKeyId": "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"}, input["SSESpecification"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"Key": "env", "Value": "prod"},
+			map[string]interface{}{"Key": "team", "Value": "backend"},
+		}, input["Tags"])
+		// they are not in CreateTable API
+		assert.Nil(t, input["TimeToLiveSpecification"])
+		assert.Nil(t, input["PointInTimeRecoverySpecification"])
+	}
+}
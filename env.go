@@ -0,0 +1,126 @@
+package gocloudurls
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// EnvSource is a source of environment variables.
+//
+// Normalizers use it to fill default values like AWS_REGION, GOOGLE_CLOUD_PROJECT or MONGO_SERVER_URL.
+// EnvList, EnvMap and EnvFunc implement this interface.
+type EnvSource interface {
+	LookupEnv(key string) (string, bool)
+}
+
+// EnvList is a EnvSource that has the form of os.Environ().
+type EnvList []string
+
+// LookupEnv implements EnvSource.
+func (e EnvList) LookupEnv(key string) (string, bool) {
+	prefix := key + "="
+	for _, env := range e {
+		if strings.HasPrefix(env, prefix) {
+			return env[len(prefix):], true
+		}
+	}
+	return "", false
+}
+
+// EnvMap is a EnvSource that is made from map.
+type EnvMap map[string]string
+
+// LookupEnv implements EnvSource.
+func (e EnvMap) LookupEnv(key string) (string, bool) {
+	value, ok := e[key]
+	return value, ok
+}
+
+// EnvFunc is a EnvSource that is made from lookup function like os.LookupEnv.
+type EnvFunc func(key string) (string, bool)
+
+// LookupEnv implements EnvSource.
+func (e EnvFunc) LookupEnv(key string) (string, bool) {
+	return e(key)
+}
+
+// OSEnv returns EnvSource that reads environment variables of current process.
+func OSEnv() EnvSource {
+	return EnvFunc(os.LookupEnv)
+}
+
+func envOrOS(env []EnvSource) EnvSource {
+	if len(env) > 0 && env[0] != nil {
+		return env[0]
+	}
+	return OSEnv()
+}
+
+func lookupEnv(env EnvSource, key string) (string, bool) {
+	if env == nil {
+		return "", false
+	}
+	return env.LookupEnv(key)
+}
+
+// gcpProject returns GCP project ID from environment variables.
+//
+// It reads GOOGLE_CLOUD_PROJECT, GCLOUD_PROJECT and CLOUDSDK_CORE_PROJECT in this order,
+// and then project_id in the credentials JSON file that GOOGLE_APPLICATION_CREDENTIALS points to.
+func gcpProject(env EnvSource) (string, bool) {
+	for _, key := range []string{"GOOGLE_CLOUD_PROJECT", "GCLOUD_PROJECT", "CLOUDSDK_CORE_PROJECT"} {
+		if project, ok := lookupEnv(env, key); ok && project != "" {
+			return project, true
+		}
+	}
+	credentials, ok := lookupEnv(env, "GOOGLE_APPLICATION_CREDENTIALS")
+	if !ok || credentials == "" {
+		return "", false
+	}
+	content, err := ioutil.ReadFile(credentials)
+	if err != nil {
+		return "", false
+	}
+	// quota_project_id of user credentials is a project for billing, not for resources
+	var c struct {
+		ProjectID string `json:"project_id"`
+	}
+	if err := json.Unmarshal(content, &c); err != nil {
+		return "", false
+	}
+	return c.ProjectID, c.ProjectID != ""
+}
+
+// awsEndpoint returns custom endpoint of AWS service (like LocalStack).
+//
+// AWS_ENDPOINT_URL_(service) is prior to AWS_ENDPOINT_URL as same as AWS SDKs.
+func awsEndpoint(env EnvSource, service string) string {
+	if endpoint, ok := lookupEnv(env, "AWS_ENDPOINT_URL_"+service); ok && endpoint != "" {
+		return endpoint
+	}
+	endpoint, _ := lookupEnv(env, "AWS_ENDPOINT_URL")
+	return endpoint
+}
+
+// setAWSEndpoint adds endpoint query parameters that are read by gocloud.dev/aws.ConfigFromURLParams.
+//
+// It does nothing if the query already has endpoint or no custom endpoint is in env.
+func setAWSEndpoint(q url.Values, env EnvSource, service string, forcePathStyle bool) {
+	if _, ok := q["endpoint"]; ok {
+		return
+	}
+	endpoint := awsEndpoint(env, service)
+	if endpoint == "" {
+		return
+	}
+	q.Set("endpoint", endpoint)
+	if strings.HasPrefix(endpoint, "http://") {
+		q.Set("disableSSL", "true")
+	}
+	if forcePathStyle {
+		q.Set("s3ForcePathStyle", "true")
+	}
+}
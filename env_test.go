@@ -0,0 +1,130 @@
+package gocloudurls
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvSource(t *testing.T) {
+	testcases := []struct {
+		name string
+		env  EnvSource
+	}{
+		{
+			name: "EnvList",
+			env:  EnvList{"AWS_REGION=us-west-1", "EMPTY="},
+		},
+		{
+			name: "EnvMap",
+			env:  EnvMap{"AWS_REGION": "us-west-1", "EMPTY": ""},
+		},
+		{
+			name: "EnvFunc",
+			env: EnvFunc(func(key string) (string, bool) {
+				return EnvMap{"AWS_REGION": "us-west-1", "EMPTY": ""}.LookupEnv(key)
+			}),
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			value, ok := testcase.env.LookupEnv("AWS_REGION")
+			assert.True(t, ok)
+			assert.Equal(t, "us-west-1", value)
+			value, ok = testcase.env.LookupEnv("EMPTY")
+			assert.True(t, ok)
+			assert.Equal(t, "", value)
+			_, ok = testcase.env.LookupEnv("AWS")
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestNormalizeWithEnviron(t *testing.T) {
+	result, err := NormalizeBlobURL("s3://my-bucket", []string{"AWS_REGION=ap-northeast-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "s3://my-bucket?region=ap-northeast-1", result)
+
+	result, err = NormalizePubSubURL("http://localhost:4566/000000000000/myqueue", EnvMap{
+		"AWS_ENDPOINT_URL": "http://localhost:4566",
+		"AWS_REGION":       "us-east-1",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "awssqs://http://localhost:4566/000000000000/myqueue?disableSSL=true&endpoint=http%3A%2F%2Flocalhost%3A4566&region=us-east-1", result)
+
+	result, err = NormalizeDocStoreURL("mongo://", Option{
+		Collection: "tasks",
+		Environ:    EnvMap{"MONGO_SERVER_URL": "mongodb://localhost:27017/my-db"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "mongo://my-db/tasks?id_field=_id", result)
+}
+
+func TestGCPProject(t *testing.T) {
+	credentials, err := ioutil.TempFile("", "credentials*.json")
+	assert.Nil(t, err)
+	defer os.Remove(credentials.Name())
+	credentials.WriteString(`{"type": "service_account", "project_id": "project-in-file"}`)
+	credentials.Close()
+	userCredentials, err := ioutil.TempFile("", "credentials*.json")
+	assert.Nil(t, err)
+	defer os.Remove(userCredentials.Name())
+	userCredentials.WriteString(`{"type": "authorized_user", "quota_project_id": "billing-project"}`)
+	userCredentials.Close()
+
+	testcases := []struct {
+		name     string
+		environ  map[string]string
+		found    bool
+		expected string
+	}{
+		{
+			name:     "GOOGLE_CLOUD_PROJECT",
+			environ:  map[string]string{"GOOGLE_CLOUD_PROJECT": "project1", "GCLOUD_PROJECT": "project2"},
+			found:    true,
+			expected: "project1",
+		},
+		{
+			name:     "GCLOUD_PROJECT",
+			environ:  map[string]string{"GCLOUD_PROJECT": "project2", "CLOUDSDK_CORE_PROJECT": "project3"},
+			found:    true,
+			expected: "project2",
+		},
+		{
+			name:     "CLOUDSDK_CORE_PROJECT",
+			environ:  map[string]string{"CLOUDSDK_CORE_PROJECT": "project3"},
+			found:    true,
+			expected: "project3",
+		},
+		{
+			name:     "GOOGLE_APPLICATION_CREDENTIALS",
+			environ:  map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": credentials.Name()},
+			found:    true,
+			expected: "project-in-file",
+		},
+		{
+			name:    "quota project of user credentials",
+			environ: map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": userCredentials.Name()},
+			found:   false,
+		},
+		{
+			name:    "credentials file doesn't exist",
+			environ: map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": credentials.Name() + ".notfound"},
+			found:   false,
+		},
+		{
+			name:    "not found",
+			environ: map[string]string{},
+			found:   false,
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			project, found := gcpProject(EnvMap(testcase.environ))
+			assert.Equal(t, testcase.found, found)
+			assert.Equal(t, testcase.expected, project)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package gocloudurls
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors of normalizers. NormalizeError wraps one of them, so application can check the cause by errors.Is.
+var (
+	// ErrUnknownScheme means that the scheme is not supported by the normalizer.
+	ErrUnknownScheme = errors.New("unknown scheme")
+	// ErrMalformedURL means that the URL (or ARN, connection string) doesn't have the expected form.
+	ErrMalformedURL = errors.New("malformed URL")
+	// ErrInvalidName means that the name of resource (topic, queue and so on) breaks the naming rule of the service.
+	ErrInvalidName = errors.New("invalid resource name")
+	// ErrMissingRegion means that region is not in URL and AWS_REGION is not set.
+	ErrMissingRegion = errors.New("missing region")
+	// ErrMissingProject means that GCP project is not in URL and environment variables.
+	ErrMissingProject = errors.New("missing project")
+	// ErrMissingCollection means that collection is not in URL and Option.Collection.
+	ErrMissingCollection = errors.New("missing collection")
+	// ErrMissingDatabase means that database name is not in URL and environment variables.
+	ErrMissingDatabase = errors.New("missing database")
+	// ErrMissingEnv means that environment variable that gocloud.dev driver needs is not set.
+	ErrMissingEnv = errors.New("missing environment variable")
+)
+
+// NormalizeError is an error of normalizers.
+//
+// Kind is a resource kind, Scheme is a scheme of URL (it may be empty for ARN or resource name), Input is the URL
+// that has problem and Field is a part of URL (query parameter, path element) or environment variable name
+// that causes the error. Err is one of the sentinel errors like ErrMissingRegion.
+//
+//   _, err := gocloudurls.NormalizeBlobURL("s3://my-bucket", nil)
+//   if errors.Is(err, gocloudurls.ErrMissingRegion) {
+//       var ne *gocloudurls.NormalizeError
+//       errors.As(err, &ne)
+//       // ne.Kind: "blob", ne.Scheme: "s3", ne.Field: "region"
+//   }
+type NormalizeError struct {
+	Kind    ResourceKind
+	Scheme  string
+	Input   string
+	Field   string
+	Err     error
+	message string
+}
+
+func (e *NormalizeError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the sentinel error for errors.Is.
+func (e *NormalizeError) Unwrap() error {
+	return e.Err
+}
+
+// withInput replaces Input of NormalizeError with the source URL that is passed to public function.
+func withInput(err error, input string) error {
+	if ne, ok := err.(*NormalizeError); ok {
+		ne.Input = input
+	}
+	return err
+}
+
+func newNormalizeError(kind ResourceKind, scheme, input, field string, err error, format string, args ...interface{}) *NormalizeError {
+	return &NormalizeError{
+		Kind:    kind,
+		Scheme:  scheme,
+		Input:   input,
+		Field:   field,
+		Err:     err,
+		message: fmt.Sprintf(format, args...),
+	}
+}
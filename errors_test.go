@@ -0,0 +1,111 @@
+package gocloudurls
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeError(t *testing.T) {
+	testcases := []struct {
+		name      string
+		normalize func() (string, error)
+		sentinel  error
+		expected  NormalizeError
+	}{
+		{
+			name: "blob: missing region",
+			normalize: func() (string, error) {
+				return NormalizeBlobURL("s3://my-bucket", []string{})
+			},
+			sentinel: ErrMissingRegion,
+			expected: NormalizeError{Kind: KindBlob, Scheme: "s3", Input: "s3://my-bucket", Field: "region"},
+		},
+		{
+			name: "blob: malformed ARN",
+			normalize: func() (string, error) {
+				return NormalizeBlobURL("arn:aws:s3:::", []string{})
+			},
+			sentinel: ErrMalformedURL,
+			expected: NormalizeError{Kind: KindBlob, Scheme: "arn", Input: "arn:aws:s3:::", Field: "bucket"},
+		},
+		{
+			name: "docstore: unknown scheme",
+			normalize: func() (string, error) {
+				return NormalizeDocStoreURL("cassandra://tasks")
+			},
+			sentinel: ErrUnknownScheme,
+			expected: NormalizeError{Kind: KindDocStore, Scheme: "cassandra", Input: "cassandra://tasks", Field: "scheme"},
+		},
+		{
+			name: "docstore: missing collection",
+			normalize: func() (string, error) {
+				return NormalizeDocStoreURL("dynamodb://")
+			},
+			sentinel: ErrMissingCollection,
+			expected: NormalizeError{Kind: KindDocStore, Scheme: "dynamodb", Input: "dynamodb://", Field: "collection"},
+		},
+		{
+			name: "docstore: missing project",
+			normalize: func() (string, error) {
+				return NormalizeDocStoreURL("firestore:///jobs", Option{Environ: EnvMap{}})
+			},
+			sentinel: ErrMissingProject,
+			expected: NormalizeError{Kind: KindDocStore, Scheme: "firestore", Input: "firestore:///jobs", Field: "project"},
+		},
+		{
+			name: "docstore: malformed path",
+			normalize: func() (string, error) {
+				return NormalizeDocStoreURL("firestore://projects/p/databases")
+			},
+			sentinel: ErrMalformedURL,
+			expected: NormalizeError{Kind: KindDocStore, Scheme: "firestore", Input: "firestore://projects/p/databases", Field: "path"},
+		},
+		{
+			name: "topic: missing project",
+			normalize: func() (string, error) {
+				return NormalizePubSubURL("gcppubsub://mytopic", EnvMap{})
+			},
+			sentinel: ErrMissingProject,
+			expected: NormalizeError{Kind: KindTopic, Scheme: "gcppubsub", Input: "gcppubsub://mytopic", Field: "project"},
+		},
+		{
+			name: "subscription: missing region",
+			normalize: func() (string, error) {
+				return NormalizeSubscriptionURL("awssqs://https://example.com/123456789012/myqueue", EnvMap{})
+			},
+			sentinel: ErrMissingRegion,
+			expected: NormalizeError{Kind: KindSubscription, Scheme: "awssqs", Input: "awssqs://https://example.com/123456789012/myqueue", Field: "region"},
+		},
+		{
+			name: "subscription: missing env",
+			normalize: func() (string, error) {
+				return NormalizeSubscriptionURL("kafka://mygroup?topic=mytopic", EnvMap{})
+			},
+			sentinel: ErrMissingEnv,
+			expected: NormalizeError{Kind: KindSubscription, Scheme: "kafka", Input: "kafka://mygroup?topic=mytopic", Field: "KAFKA_BROKERS"},
+		},
+		{
+			name: "topic: invalid name",
+			normalize: func() (string, error) {
+				return NormalizePubSubURL("projects/myproject/topics/goog-topic", EnvMap{})
+			},
+			sentinel: ErrInvalidName,
+			expected: NormalizeError{Kind: KindTopic, Scheme: "gcppubsub", Input: "projects/myproject/topics/goog-topic", Field: "topic"},
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			_, err := testcase.normalize()
+			assert.True(t, errors.Is(err, testcase.sentinel))
+			var ne *NormalizeError
+			if assert.True(t, errors.As(err, &ne)) {
+				assert.Equal(t, testcase.expected.Kind, ne.Kind)
+				assert.Equal(t, testcase.expected.Scheme, ne.Scheme)
+				assert.Equal(t, testcase.expected.Input, ne.Input)
+				assert.Equal(t, testcase.expected.Field, ne.Field)
+			}
+		})
+	}
+}
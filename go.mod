@@ -1,5 +1,8 @@
 module github.com/future-architect/gocloudurls
 
-go 1.12
+go 1.13
 
-require github.com/stretchr/testify v1.4.0
+require (
+	github.com/stretchr/testify v1.4.0
+	gopkg.in/yaml.v2 v2.2.2
+)
@@ -0,0 +1,170 @@
+package gocloudurls
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// FieldError is an error of a config field that Load normalizes. Value is the raw value that is normalized
+// (read from environment variable if it is set). Password in Value and error message is replaced with "xxxxx".
+type FieldError struct {
+	Field string
+	Value string
+	Err   error
+}
+
+func (f FieldError) Error() string {
+	return fmt.Sprintf("%s ('%s'): %s", f.Field, f.Value, redactValue(f.Err.Error()))
+}
+
+// LoadError is returned by Load. It has errors of all bad fields.
+type LoadError []FieldError
+
+func (l LoadError) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d config fields have errors: %s", len(l), strings.Join(messages, ", "))
+}
+
+// Load normalizes string fields of config struct that have ``gocloud`` tag. environ has the form of os.Environ().
+//
+// Tag value is resource kind (blob, docstore, topic, subscription, secret, runtimevar or sql) and options:
+//
+//   * ``env=NAME``: raw value is read from the environment variable. If it is not set or empty, current field value is used.
+//   * ``required``: empty value is an error.
+//   * ``prefix=tenant-a``: BlobOption.Prefix
+//   * ``collection=tasks``, ``key=id``, ``partition_key=job_id``: Option for docstore
+//   * ``decoder=json``: RuntimeVarOption.Decoder
+//   * ``database=mydb``, ``engine=postgres``: SQLOption
+//
+// Nested struct fields are also loaded. It returns LoadError that has errors of all bad fields.
+//
+// Example:
+//
+//   type Config struct {
+//       Uploads string `gocloud:"blob,env=UPLOAD_BUCKET,required"`
+//       Tasks   string `gocloud:"docstore,collection=tasks,key=id"`
+//       Events  string `gocloud:"topic"`
+//   }
+//   cfg := Config{Tasks: "dynamodb://", Events: "arn:aws:sns:us-east-2:123456789012:events"}
+//   err := gocloudurls.Load(&cfg, os.Environ())
+func Load(cfg interface{}, environ []string) error {
+	v := reflect.ValueOf(cfg)
+	if !v.IsValid() {
+		return errors.New("cfg should be pointer of struct, but nil")
+	}
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cfg should be pointer of struct, but %s", v.Type().String())
+	}
+	var errs LoadError
+	loadStruct(v.Elem(), "", EnvList(environ), &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func loadStruct(sv reflect.Value, parent string, env EnvList, errs *LoadError) {
+	for i := 0; i < sv.Type().NumField(); i++ {
+		f := sv.Type().Field(i)
+		fv := sv.Field(i)
+		name := parent + f.Name
+		tag, ok := f.Tag.Lookup("gocloud")
+		if !ok {
+			if f.Type.Kind() == reflect.Struct && f.PkgPath == "" {
+				loadStruct(fv, name+".", env, errs)
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		if f.Type.Kind() != reflect.String || !fv.CanSet() {
+			*errs = append(*errs, FieldError{Field: name, Err: errors.New("gocloud tag is only for exported string field")})
+			continue
+		}
+		value, raw, err := loadField(fv.String(), tag, env)
+		if err != nil {
+			*errs = append(*errs, FieldError{Field: name, Value: redactValue(raw), Err: err})
+			continue
+		}
+		fv.SetString(value)
+	}
+}
+
+// loadField returns normalized value and raw value that is normalized.
+func loadField(value, tag string, env EnvList) (string, string, error) {
+	fragments := strings.Split(tag, ",")
+	kind := ResourceKind(fragments[0])
+	o := NormalizeOption{Environ: env}
+	required := false
+	for _, option := range fragments[1:] {
+		keyValue := strings.SplitN(option, "=", 2)
+		if keyValue[0] == "required" {
+			required = true
+			continue
+		}
+		if len(keyValue) != 2 {
+			return "", value, fmt.Errorf("option of gocloud tag should be key=value, but '%s'", option)
+		}
+		switch keyValue[0] {
+		case "env":
+			if envValue, ok := env.LookupEnv(keyValue[1]); ok && envValue != "" {
+				value = envValue
+			}
+		case "prefix":
+			o.Blob.Prefix = keyValue[1]
+		case "collection":
+			o.DocStore.Collection = keyValue[1]
+		case "key":
+			o.DocStore.KeyName = keyValue[1]
+		case "partition_key":
+			o.DocStore.PartitionKey = keyValue[1]
+		case "decoder":
+			o.RuntimeVar.Decoder = keyValue[1]
+		case "database":
+			o.SQL.Database = keyValue[1]
+		case "engine":
+			o.SQL.Engine = keyValue[1]
+		default:
+			return "", value, fmt.Errorf("Unknown option of gocloud tag: '%s'", keyValue[0])
+		}
+	}
+	if value == "" {
+		if required {
+			return "", value, errors.New("value is required")
+		}
+		return "", value, nil
+	}
+	result, err := Normalize(kind, value, o)
+	return result, value, err
+}
+
+var (
+	urlPassword             = regexp.MustCompile(`(://[^\s:@/?#]*):[^\s/?#]*@`)
+	userInfoPassword        = regexp.MustCompile(`^([^\s:@/]+):[^\s/]*@`)
+	dsnPassword             = regexp.MustCompile(`(^|[\s'"])([^\s'":@/]*):[^\s@]*@((?:tcp|unix)\()`)
+	keyValuePassword        = regexp.MustCompile(`(^|\s)password=(?:'[^']*'|\S*)`)
+	connectionStringSecrets = regexp.MustCompile(`(SharedAccessKey|AccountKey|Password)=[^;]*`)
+)
+
+// redactValue hides password of URL, DSN and connection string for errors.
+// It also works for error messages that include them (even if URL can't be parsed).
+func redactValue(value string) string {
+	if strings.Contains(value, "://") {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			return redactSQLURL(u)
+		}
+	}
+	value = urlPassword.ReplaceAllString(value, "${1}:xxxxx@")
+	value = userInfoPassword.ReplaceAllString(value, "${1}:xxxxx@")
+	value = dsnPassword.ReplaceAllString(value, "${1}${2}:xxxxx@${3}")
+	value = keyValuePassword.ReplaceAllString(value, "${1}password=xxxxx")
+	return connectionStringSecrets.ReplaceAllString(value, "${1}=xxxxx")
+}
@@ -0,0 +1,275 @@
+package gocloudurls
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// BlobLocation is a parsed form of blob URL.
+//
+// Provider is a scheme of gocloud.dev URL (s3, gs, azblob, file, mem).
+// Params keeps other query parameters like storage_account or endpoint.
+type BlobLocation struct {
+	Provider string
+	Bucket   string
+	Region   string
+	Prefix   string
+	Params   url.Values
+}
+
+// ParseBlobURL normalizes URL by NormalizeBlobURL and returns the parsed result.
+//
+// Example:
+//
+//   loc, err := gocloudurls.ParseBlobURL("s3://my-bucket/uploads", os.Environ())
+//   // loc.Bucket: "my-bucket", loc.Region: "us-west-1", loc.Prefix: "uploads/"
+func ParseBlobURL(srcUrl string, environ []string, opt ...BlobOption) (*BlobLocation, error) {
+	normalized, err := NormalizeBlobURL(srcUrl, environ, opt...)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	result := &BlobLocation{
+		Provider: u.Scheme,
+		Bucket:   u.Host,
+		Region:   q.Get("region"),
+		Prefix:   q.Get("prefix"),
+	}
+	if u.Scheme == "file" && u.Host == "" {
+		result.Bucket = u.Path
+	}
+	q.Del("region")
+	q.Del("prefix")
+	result.Params = q
+	return result, nil
+}
+
+// String returns normalized blob URL.
+func (b BlobLocation) String() string {
+	q := copyValues(b.Params)
+	if b.Region != "" {
+		q.Set("region", b.Region)
+	}
+	if b.Prefix != "" {
+		q.Set("prefix", b.Prefix)
+	}
+	u := &url.URL{
+		Scheme:   b.Provider,
+		Host:     b.Bucket,
+		RawQuery: q.Encode(),
+	}
+	if b.Provider == "file" && strings.HasPrefix(b.Bucket, "/") {
+		u.Host = ""
+		u.Path = b.Bucket
+	}
+	return u.String()
+}
+
+// TopicLocation is a parsed form of PubSub URL.
+//
+// Provider is a scheme of gocloud.dev URL (gcppubsub, awssns, awssqs and so on).
+// Topic is a topic name of Cloud Pub/Sub or SNS, or a queue name of SQS.
+// QueueURL is only for SQS.
+type TopicLocation struct {
+	Provider  string
+	Project   string
+	Topic     string
+	ARN       string
+	AccountID string
+	Region    string
+	QueueURL  string
+	Params    url.Values
+}
+
+// ParsePubSubURL normalizes URL by NormalizePubSubURL and returns the parsed result.
+//
+// Example:
+//
+//   loc, err := gocloudurls.ParsePubSubURL("arn:aws:sns:us-east-2:123456789012:mytopic")
+//   // loc.Topic: "mytopic", loc.AccountID: "123456789012", loc.Region: "us-east-2"
+func ParsePubSubURL(srcUrl string, env ...EnvSource) (*TopicLocation, error) {
+	normalized, err := NormalizePubSubURL(srcUrl, env...)
+	if err != nil {
+		return nil, err
+	}
+	return parseTopicLocation(normalized)
+}
+
+func parseTopicLocation(normalized string) (*TopicLocation, error) {
+	if strings.HasPrefix(normalized, "awssqs://") {
+		u, err := url.Parse(strings.TrimPrefix(normalized, "awssqs://"))
+		if err != nil {
+			return nil, err
+		}
+		q := u.Query()
+		fragments := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(fragments) != 2 {
+			return nil, fmt.Errorf("SQS URL should have account ID and queue name, but '%s'", normalized)
+		}
+		result := &TopicLocation{
+			Provider:  "awssqs",
+			Topic:     fragments[1],
+			AccountID: fragments[0],
+			Region:    q.Get("region"),
+		}
+		result.ARN = strings.Join([]string{"arn", "aws", "sqs", result.Region, result.AccountID, result.Topic}, ":")
+		u.RawQuery = ""
+		result.QueueURL = u.String()
+		q.Del("region")
+		result.Params = q
+		return result, nil
+	}
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	result := &TopicLocation{
+		Provider: u.Scheme,
+	}
+	switch u.Scheme {
+	case "awssns":
+		result.ARN = strings.TrimPrefix(u.Path, "/")
+		fragments := strings.Split(result.ARN, ":")
+		if len(fragments) != 6 {
+			return nil, fmt.Errorf("SNS topic ARN should be arn:aws:sns:(region):(account):(topic), but '%s'", normalized)
+		}
+		result.AccountID = fragments[4]
+		result.Topic = fragments[5]
+		result.Region = q.Get("region")
+		q.Del("region")
+	case "gcppubsub":
+		fragments := strings.Split(u.Path, "/")
+		if u.Host != "projects" || len(fragments) != 4 || fragments[2] != "topics" {
+			return nil, fmt.Errorf("gcppubsub url should be gcppubsub://projects/(project)/topics/(topic), but '%s'", normalized)
+		}
+		result.Project = fragments[1]
+		result.Topic = fragments[3]
+	default:
+		result.Topic = u.Host + u.Path
+	}
+	result.Params = q
+	return result, nil
+}
+
+// String returns normalized PubSub URL.
+func (t TopicLocation) String() string {
+	q := copyValues(t.Params)
+	switch t.Provider {
+	case "awssqs":
+		q.Set("region", t.Region)
+		return "awssqs://" + t.QueueURL + "?" + q.Encode()
+	case "awssns":
+		q.Set("region", t.Region)
+		return (&url.URL{Scheme: "awssns", Path: "/" + t.ARN, RawQuery: q.Encode()}).String()
+	case "gcppubsub":
+		return (&url.URL{Scheme: "gcppubsub", Host: "projects", Path: "/" + t.Project + "/topics/" + t.Topic, RawQuery: q.Encode()}).String()
+	}
+	return withQuery(t.Provider+"://"+t.Topic, q)
+}
+
+// CollectionLocation is a parsed form of DocStore URL.
+//
+// Provider is a scheme of gocloud.dev URL (firestore, dynamodb, mongo, mem).
+// PartitionKey keeps name_field of Firestore, id_field of MongoDB and key field of memdocstore.
+// SortKey is only for DynamoDB.
+type CollectionLocation struct {
+	Provider     string
+	Project      string
+	Database     string
+	Collection   string
+	PartitionKey string
+	SortKey      string
+	Params       url.Values
+}
+
+// ParseDocStoreURL normalizes URL by NormalizeDocStoreURL and returns the parsed result.
+//
+// Example:
+//
+//   loc, err := gocloudurls.ParseDocStoreURL("firestore://my-project", gocloudurls.Option{
+//       Collection: "addresses",
+//   })
+//   // loc.Project: "my-project", loc.Database: "(default)", loc.Collection: "addresses", loc.PartitionKey: "_id"
+func ParseDocStoreURL(srcUrl string, opt ...Option) (*CollectionLocation, error) {
+	normalized, err := NormalizeDocStoreURL(srcUrl, opt...)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	result := &CollectionLocation{
+		Provider: u.Scheme,
+	}
+	switch u.Scheme {
+	case "firestore":
+		fragments := strings.Split(u.Path, "/")
+		if len(fragments) != 6 {
+			return nil, fmt.Errorf("Firestore URL should be firestore://projects/(prj)/databases/(db)/documents/(docs), but '%s'", normalized)
+		}
+		result.Project = fragments[1]
+		result.Database = fragments[3]
+		result.Collection = fragments[5]
+		result.PartitionKey = q.Get("name_field")
+		q.Del("name_field")
+	case "dynamodb":
+		result.Collection = u.Host
+		result.PartitionKey = q.Get("partition_key")
+		result.SortKey = q.Get("sort_key")
+		q.Del("partition_key")
+		q.Del("sort_key")
+	case "mongo":
+		result.Database = u.Host
+		result.Collection = strings.TrimPrefix(u.Path, "/")
+		result.PartitionKey = q.Get("id_field")
+		q.Del("id_field")
+	case "mem":
+		result.Collection = u.Host
+		result.PartitionKey = strings.TrimPrefix(u.Path, "/")
+	}
+	result.Params = q
+	return result, nil
+}
+
+// String returns normalized DocStore URL.
+func (c CollectionLocation) String() string {
+	q := copyValues(c.Params)
+	switch c.Provider {
+	case "firestore":
+		q.Set("name_field", c.PartitionKey)
+		return withQuery("firestore://projects/"+c.Project+"/databases/"+c.Database+"/documents/"+c.Collection, q)
+	case "dynamodb":
+		q.Set("partition_key", c.PartitionKey)
+		if c.SortKey != "" {
+			q.Set("sort_key", c.SortKey)
+		}
+		return withQuery("dynamodb://"+c.Collection, q)
+	case "mongo":
+		q.Set("id_field", c.PartitionKey)
+		return withQuery("mongo://"+c.Database+"/"+c.Collection, q)
+	}
+	return withQuery(c.Provider+"://"+c.Collection+"/"+c.PartitionKey, q)
+}
+
+func copyValues(src url.Values) url.Values {
+	result := make(url.Values)
+	for k, v := range src {
+		result[k] = append([]string(nil), v...)
+	}
+	return result
+}
+
+func withQuery(base string, q url.Values) string {
+	if len(q) == 0 {
+		return base
+	}
+	return base + "?" + q.Encode()
+}
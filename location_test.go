@@ -0,0 +1,202 @@
+package gocloudurls
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBlobURL(t *testing.T) {
+	testcases := []struct {
+		name     string
+		src      string
+		expected BlobLocation
+		url      string
+	}{
+		{
+			name: "s3",
+			src:  "s3://my-bucket/tenant-a?region=us-west-1",
+			expected: BlobLocation{
+				Provider: "s3",
+				Bucket:   "my-bucket",
+				Region:   "us-west-1",
+				Prefix:   "tenant-a/",
+				Params:   url.Values{},
+			},
+			url: "s3://my-bucket?prefix=tenant-a%2F&region=us-west-1",
+		},
+		{
+			name: "azblob",
+			src:  "https://myaccount.blob.core.windows.net/my-container",
+			expected: BlobLocation{
+				Provider: "azblob",
+				Bucket:   "my-container",
+				Params:   url.Values{"storage_account": []string{"myaccount"}},
+			},
+			url: "azblob://my-container?storage_account=myaccount",
+		},
+		{
+			name: "absolute file",
+			src:  "/var/data",
+			expected: BlobLocation{
+				Provider: "file",
+				Bucket:   "/var/data",
+				Params:   url.Values{},
+			},
+			url: "file:///var/data",
+		},
+		{
+			name: "mem",
+			src:  "mem",
+			expected: BlobLocation{
+				Provider: "mem",
+				Params:   url.Values{},
+			},
+			url: "mem:",
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := ParseBlobURL(testcase.src, nil)
+			assert.Nil(t, err)
+			if err == nil {
+				assert.Equal(t, testcase.expected, *result)
+				assert.Equal(t, testcase.url, result.String())
+			}
+		})
+	}
+}
+
+func TestParsePubSubURL(t *testing.T) {
+	testcases := []struct {
+		name     string
+		src      string
+		expected TopicLocation
+		url      string
+	}{
+		{
+			name: "SNS",
+			src:  "arn:aws:sns:us-east-2:123456789012:mytopic",
+			expected: TopicLocation{
+				Provider:  "awssns",
+				Topic:     "mytopic",
+				ARN:       "arn:aws:sns:us-east-2:123456789012:mytopic",
+				AccountID: "123456789012",
+				Region:    "us-east-2",
+				Params:    url.Values{},
+			},
+			url: "awssns:///arn:aws:sns:us-east-2:123456789012:mytopic?region=us-east-2",
+		},
+		{
+			name: "SQS",
+			src:  "https://sqs.us-east-2.amazonaws.com/123456789012/myqueue",
+			expected: TopicLocation{
+				Provider:  "awssqs",
+				Topic:     "myqueue",
+				ARN:       "arn:aws:sqs:us-east-2:123456789012:myqueue",
+				AccountID: "123456789012",
+				Region:    "us-east-2",
+				QueueURL:  "https://sqs.us-east-2.amazonaws.com/123456789012/myqueue",
+				Params:    url.Values{},
+			},
+			url: "awssqs://https://sqs.us-east-2.amazonaws.com/123456789012/myqueue?region=us-east-2",
+		},
+		{
+			name: "Cloud Pub/Sub",
+			src:  "gcppubsub://myproject/mytopic",
+			expected: TopicLocation{
+				Provider: "gcppubsub",
+				Project:  "myproject",
+				Topic:    "mytopic",
+				Params:   url.Values{},
+			},
+			url: "gcppubsub://projects/myproject/topics/mytopic",
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := ParsePubSubURL(testcase.src)
+			assert.Nil(t, err)
+			if err == nil {
+				assert.Equal(t, testcase.expected, *result)
+				assert.Equal(t, testcase.url, result.String())
+			}
+		})
+	}
+}
+
+func TestParseDocStoreURL(t *testing.T) {
+	testcases := []struct {
+		name     string
+		src      string
+		opt      Option
+		expected CollectionLocation
+		url      string
+	}{
+		{
+			name: "Firestore",
+			src:  "firestore://my-project",
+			opt:  Option{Collection: "addresses"},
+			expected: CollectionLocation{
+				Provider:     "firestore",
+				Project:      "my-project",
+				Database:     "(default)",
+				Collection:   "addresses",
+				PartitionKey: "_id",
+				Params:       url.Values{},
+			},
+			url: "firestore://projects/my-project/databases/(default)/documents/addresses?name_field=_id",
+		},
+		{
+			name: "DynamoDB",
+			src:  "dynamodb://",
+			opt:  Option{Collection: "tasks", PartitionKey: "job_id"},
+			expected: CollectionLocation{
+				Provider:     "dynamodb",
+				Collection:   "tasks",
+				PartitionKey: "job_id",
+				SortKey:      "_id",
+				Params:       url.Values{},
+			},
+			url: "dynamodb://tasks?partition_key=job_id&sort_key=_id",
+		},
+		{
+			name: "MongoDB",
+			src:  "mongo://my-db",
+			opt:  Option{Collection: "tasks"},
+			expected: CollectionLocation{
+				Provider:     "mongo",
+				Database:     "my-db",
+				Collection:   "tasks",
+				PartitionKey: "_id",
+				Params:       url.Values{},
+			},
+			url: "mongo://my-db/tasks?id_field=_id",
+		},
+		{
+			name: "Mem",
+			src:  "mem://",
+			opt:  Option{Collection: "tasks", FileName: "local.memdb"},
+			expected: CollectionLocation{
+				Provider:     "mem",
+				Collection:   "tasks",
+				PartitionKey: "_id",
+				Params:       url.Values{"filename": []string{"local.memdb"}},
+			},
+			url: "mem://tasks/_id?filename=local.memdb",
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := ParseDocStoreURL(testcase.src, testcase.opt)
+			assert.Nil(t, err)
+			if err == nil {
+				assert.Equal(t, testcase.expected, *result)
+				assert.Equal(t, testcase.url, result.String())
+				normalized, _ := NormalizeDocStoreURL(testcase.src, testcase.opt)
+				assert.Equal(t, normalized, result.String())
+			}
+		})
+	}
+}
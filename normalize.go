@@ -0,0 +1,238 @@
+package gocloudurls
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ResourceKind is a kind of cloud resource that gocloud.dev opens
+type ResourceKind string
+
+// Resource kinds that Normalize supports
+const (
+	KindBlob         ResourceKind = "blob"
+	KindDocStore     ResourceKind = "docstore"
+	KindTopic        ResourceKind = "topic"
+	KindSubscription ResourceKind = "subscription"
+	KindSecret       ResourceKind = "secret"
+	KindRuntimeVar   ResourceKind = "runtimevar"
+	KindSQL          ResourceKind = "sql"
+)
+
+// NormalizeOption is a option for Normalize
+//
+// Environ is a source of environment variables for all kinds. If it is nil, environment variables
+// of current process are used. DocStore.Environ and RuntimeVar.Environ are prior to it.
+//
+// Other fields are passed to the normalizer of each kind.
+type NormalizeOption struct {
+	Environ    EnvSource
+	Blob       BlobOption
+	DocStore   Option
+	RuntimeVar RuntimeVarOption
+	SQL        SQLOption
+}
+
+// Normalize normalizes URL of any resource kind.
+//
+// It calls NormalizeBlobURL, NormalizeDocStoreURL, NormalizePubSubURL, NormalizeSubscriptionURL,
+// NormalizeSecretsURL, NormalizeRuntimeVarURL or NormalizeSQLURL. If kind is empty, it is detected by DetectKind.
+//
+// Example:
+//
+//   config := map[string]string{
+//       "uploads": "s3://my-bucket",
+//       "events":  "arn:aws:sns:us-east-2:123456789012:mytopic",
+//   }
+//   for name, src := range config {
+//       config[name], err = gocloudurls.Normalize("", src)
+//   }
+func Normalize(kind ResourceKind, srcUrl string, opt ...NormalizeOption) (string, error) {
+	var o NormalizeOption
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	if kind == "" {
+		var err error
+		kind, err = DetectKind(srcUrl)
+		if err != nil {
+			return "", err
+		}
+	}
+	env := envOrOS([]EnvSource{o.Environ})
+	switch kind {
+	case KindBlob:
+		return normalizeBlobURLWithOption(srcUrl, env, o.Blob)
+	case KindDocStore:
+		if o.DocStore.Environ == nil {
+			o.DocStore.Environ = env
+		}
+		return NormalizeDocStoreURL(srcUrl, o.DocStore)
+	case KindTopic:
+		return NormalizePubSubURL(srcUrl, env)
+	case KindSubscription:
+		return NormalizeSubscriptionURL(srcUrl, env)
+	case KindSecret:
+		return NormalizeSecretsURL(srcUrl, env)
+	case KindRuntimeVar:
+		if o.RuntimeVar.Environ == nil {
+			o.RuntimeVar.Environ = env
+		}
+		return NormalizeRuntimeVarURL(srcUrl, o.RuntimeVar)
+	case KindSQL:
+		return NormalizeSQLURL(srcUrl, o.SQL)
+	}
+	return "", fmt.Errorf("Unknown resource kind: '%s'", kind)
+}
+
+// MustNormalize is similar to Normalize but raise panic if there is error
+func MustNormalize(kind ResourceKind, srcUrl string, opt ...NormalizeOption) string {
+	result, err := Normalize(kind, srcUrl, opt...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// schemeKinds maps gocloud.dev URL schemes into resource kinds.
+//
+// "mem" and "file" are used by both blob and docstore (or runtimevar). They are handled in DetectKind.
+var schemeKinds = map[string]ResourceKind{
+	"s3":                KindBlob,
+	"gs":                KindBlob,
+	"azblob":            KindBlob,
+	"firestore":         KindDocStore,
+	"dynamodb":          KindDocStore,
+	"mongo":             KindDocStore,
+	"awssns":            KindTopic,
+	"nats":              KindTopic,
+	"rabbit":            KindTopic,
+	"awssqs":            KindSubscription,
+	"awskms":            KindSecret,
+	"gcpkms":            KindSecret,
+	"azurekeyvault":     KindSecret,
+	"hashivault":        KindSecret,
+	"base64key":         KindSecret,
+	"awsparamstore":     KindRuntimeVar,
+	"awssecretsmanager": KindRuntimeVar,
+	"gcpruntimeconfig":  KindRuntimeVar,
+	"gcpsecretmanager":  KindRuntimeVar,
+	"constant":          KindRuntimeVar,
+	"mysql":             KindSQL,
+	"postgres":          KindSQL,
+	"postgresql":        KindSQL,
+	"awsmysql":          KindSQL,
+	"awspostgres":       KindSQL,
+	"gcpmysql":          KindSQL,
+	"gcppostgres":       KindSQL,
+}
+
+// arnServiceKinds maps service names in ARN into resource kinds.
+var arnServiceKinds = map[string]ResourceKind{
+	"s3":             KindBlob,
+	"sns":            KindTopic,
+	"sqs":            KindSubscription,
+	"kms":            KindSecret,
+	"ssm":            KindRuntimeVar,
+	"secretsmanager": KindRuntimeVar,
+}
+
+// DetectKind guesses resource kind from the scheme, ARN service or the form of resource name.
+//
+// SQS queue is detected as subscription and NATS subject or RabbitMQ exchange is detected as topic.
+// "mem://" is blob and "mem://(collection)" is docstore. "file://" is blob.
+//
+// Examples:
+//
+//   * "s3://my-bucket" → KindBlob
+//   * "arn:aws:sns:us-east-2:123456789012:mytopic" → KindTopic
+//   * "projects/p/subscriptions/s" → KindSubscription
+//   * "projects/p/locations/l/keyRings/r/cryptoKeys/k" → KindSecret
+//   * "arn:aws:ssm:us-east-2:123456789012:parameter/app/config" → KindRuntimeVar
+func DetectKind(srcUrl string) (ResourceKind, error) {
+	switch {
+	case srcUrl == "mem":
+		return KindBlob, nil
+	case srcUrl == "local" || strings.HasPrefix(srcUrl, "alias/"):
+		return KindSecret, nil
+	case strings.HasPrefix(srcUrl, "arn:"):
+		fragments := strings.Split(srcUrl, ":")
+		if len(fragments) > 2 {
+			if kind, ok := arnServiceKinds[fragments[2]]; ok {
+				return kind, nil
+			}
+		}
+	case strings.HasPrefix(srcUrl, "projects/"):
+		return detectGCPResourceKind(strings.Split(srcUrl, "/"))
+	case strings.HasPrefix(srcUrl, "Endpoint=sb://"):
+		if strings.Contains(strings.ToLower(srcUrl), "/subscriptions/") {
+			return KindSubscription, nil
+		}
+		return KindTopic, nil
+	}
+	u, err := url.Parse(srcUrl)
+	if err != nil {
+		return "", err
+	}
+	if kind, ok := schemeKinds[u.Scheme]; ok {
+		return kind, nil
+	}
+	if kind, ok := registeredKind(u.Scheme); ok {
+		return kind, nil
+	}
+	q := u.Query()
+	switch u.Scheme {
+	case "mem":
+		if u.Host != "" {
+			return KindDocStore, nil
+		}
+		return KindBlob, nil
+	case "file":
+		return KindBlob, nil
+	case "gcppubsub":
+		if strings.Contains(u.Path, "/subscriptions/") {
+			return KindSubscription, nil
+		}
+		return KindTopic, nil
+	case "azuresb":
+		if q.Get("subscription") != "" {
+			return KindSubscription, nil
+		}
+		return KindTopic, nil
+	case "kafka":
+		if q.Get("topic") != "" || q.Get("group") != "" {
+			return KindSubscription, nil
+		}
+		return KindTopic, nil
+	case "https":
+		host := u.Hostname()
+		switch {
+		case strings.HasPrefix(host, "sqs.") || sqsRegion(host) != "":
+			return KindSubscription, nil
+		case strings.HasSuffix(host, ".vault.azure.net"):
+			return KindSecret, nil
+		case strings.HasSuffix(host, ".amazonaws.com") || strings.HasSuffix(host, azureBlobHostSuffix) || host == "s3.console.aws.amazon.com" ||
+			host == "storage.googleapis.com" || host == "storage.cloud.google.com" || host == "console.cloud.google.com":
+			return KindBlob, nil
+		}
+	}
+	return "", fmt.Errorf("Can't detect resource kind of '%s'", srcUrl)
+}
+
+// detectGCPResourceKind detects resource kind from GCP resource name (projects/(project)/...).
+func detectGCPResourceKind(fragments []string) (ResourceKind, error) {
+	if len(fragments) > 2 {
+		switch fragments[2] {
+		case "topics":
+			return KindTopic, nil
+		case "subscriptions":
+			return KindSubscription, nil
+		case "locations":
+			return KindSecret, nil
+		case "configs", "secrets":
+			return KindRuntimeVar, nil
+		}
+	}
+	return "", fmt.Errorf("Can't detect resource kind of '%s'", strings.Join(fragments, "/"))
+}
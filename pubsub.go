@@ -1,9 +1,9 @@
 package gocloudurls
 
 import (
-	"errors"
 	"net/url"
 	"path"
+	"regexp"
 	"strings"
 )
 
@@ -15,88 +15,320 @@ import (
 //     → "awssns:///arn:aws:sns:us-east-2:123456789012:mytopic?region=us-east-2"
 //   * "https://sqs.us-east-2.amazonaws.com/123456789012/myqueue"
 //     → "awssqs://https://sqs.us-east-2.amazonaws.com/123456789012/myqueue?region=us-east-2"
+//   * "arn:aws:sqs:us-east-2:123456789012:myqueue"
+//     → "awssqs://https://sqs.us-east-2.amazonaws.com/123456789012/myqueue?region=us-east-2"
+//   * "https://us-east-2.queue.amazonaws.com/123456789012/myqueue"
+//     → "awssqs://https://us-east-2.queue.amazonaws.com/123456789012/myqueue?region=us-east-2"
 //   * "gcppubsub://myproject/mytopic"
 //     → "gcppubsub://projects/myproject/topics/mytopic"
-func NormalizePubSubURL(srcUrl string) (string, error) {
-	if isAWSPubSub(srcUrl) {
-		return normalizeAWSPubSub(srcUrl)
-	} else if strings.HasPrefix(srcUrl, "gcppubsub://") {
-		return normalizeGCPPubSub(srcUrl)
+//
+// SQS queue name is validated. FIFO queue should have ".fifo" suffix. Messages that are sent to FIFO queue
+// require message group ID metadata (awssnssqs.MetadataKeyMessageGroupID) in application code.
+//
+// If Cloud Pub/Sub URL doesn't have project name (like "gcppubsub://mytopic"), it is got from
+// GOOGLE_CLOUD_PROJECT, GCLOUD_PROJECT, CLOUDSDK_CORE_PROJECT environment variables or the credentials JSON file
+// that GOOGLE_APPLICATION_CREDENTIALS points to.
+//
+// If AWS_ENDPOINT_URL_SQS, AWS_ENDPOINT_URL_SNS or AWS_ENDPOINT_URL environment variable is set
+// (for LocalStack and so on), ``endpoint`` and ``disableSSL`` query parameters are added to SQS/SNS URL.
+// Queue URL that starts with the SQS endpoint is treated as SQS queue:
+//
+//   * "http://localhost:4566/000000000000/myqueue" (AWS_ENDPOINT_URL=http://localhost:4566, AWS_REGION=us-east-1)
+//     → "awssqs://http://localhost:4566/000000000000/myqueue?disableSSL=true&endpoint=http%3A%2F%2Flocalhost%3A4566&region=us-east-1"
+//
+// Azure Service Bus URL (azuresb://mytopic) and the connection string copied from Azure portal
+// ("Endpoint=sb://ns.servicebus.windows.net/;...;EntityPath=mytopic") are accepted. Connection string is not
+// put into URL. It should be in SERVICEBUS_CONNECTION_STRING environment variable.
+//
+// Kafka (kafka://), NATS (nats://) and RabbitMQ (rabbit://) URLs are validated. Server addresses should be in
+// KAFKA_BROKERS, NATS_SERVER_URL and RABBIT_SERVER_URL environment variables.
+//
+// env is a source of environment variables. If it is omitted, environment variables of current process are used.
+func NormalizePubSubURL(srcUrl string, env ...EnvSource) (string, error) {
+	e := envOrOS(env)
+	if isAWSPubSub(srcUrl, e) {
+		return normalizeAWSPubSub(srcUrl, KindTopic, e)
+	} else if strings.HasPrefix(srcUrl, "gcppubsub://") || strings.HasPrefix(srcUrl, "projects/") {
+		return normalizeGCPPubSub(srcUrl, e)
+	} else if isAzureServiceBus(srcUrl) {
+		return normalizeAzureServiceBus(srcUrl, false, e)
+	} else if isBrokerPubSub(srcUrl) {
+		return normalizeBrokerPubSub(srcUrl, false, e)
+	} else if result, ok, err := normalizeRegistered(KindTopic, srcUrl, NormalizeOption{Environ: e}); ok {
+		return result, err
+	}
+	return srcUrl, nil
+}
+
+// NormalizeSubscriptionURL normalize URL for PubSub subscription.
+//
+// Examples:
+//
+//   * "gcppubsub://myproject/mysubscription"
+//     → "gcppubsub://projects/myproject/subscriptions/mysubscription"
+//   * "projects/myproject/subscriptions/mysubscription"
+//     → "gcppubsub://projects/myproject/subscriptions/mysubscription"
+//   * "https://sqs.us-east-2.amazonaws.com/123456789012/myqueue"
+//     → "awssqs://https://sqs.us-east-2.amazonaws.com/123456789012/myqueue?region=us-east-2"
+//   * "kafka://mytopic?group=mygroup"
+//     → "kafka://mygroup?topic=mytopic"
+//   * "azuresb://mytopic?subscription=mysub"
+//     → "azuresb://mytopic?subscription=mysub"
+//
+// As same as NormalizePubSubURL, project name can be omitted if it is in environment variables.
+func NormalizeSubscriptionURL(srcUrl string, env ...EnvSource) (string, error) {
+	e := envOrOS(env)
+	if isSQSURL(srcUrl, e) {
+		return normalizeAWSPubSub(srcUrl, KindSubscription, e)
+	} else if strings.HasPrefix(srcUrl, "gcppubsub://") || strings.HasPrefix(srcUrl, "projects/") {
+		return normalizeGCPPubSubResource(srcUrl, "subscriptions", e)
+	} else if isAzureServiceBus(srcUrl) {
+		return normalizeAzureServiceBus(srcUrl, true, e)
+	} else if isBrokerPubSub(srcUrl) {
+		return normalizeBrokerPubSub(srcUrl, true, e)
+	} else if result, ok, err := normalizeRegistered(KindSubscription, srcUrl, NormalizeOption{Environ: e}); ok {
+		return result, err
 	}
 	return srcUrl, nil
 }
 
+// MustNormalizeSubscriptionURL is similar to NormalizeSubscriptionURL but raise panic if there is error
+func MustNormalizeSubscriptionURL(srcUrl string, env ...EnvSource) string {
+	result, err := NormalizeSubscriptionURL(srcUrl, env...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
 // MustNormalizePubSubURL is similar to NormalizePubSubURL but raise panic if there is error
-func MustNormalizePubSubURL(srcUrl string) string {
-	result, err := NormalizePubSubURL(srcUrl)
+func MustNormalizePubSubURL(srcUrl string, env ...EnvSource) string {
+	result, err := NormalizePubSubURL(srcUrl, env...)
 	if err != nil {
 		panic(err)
 	}
 	return result
 }
 
-func isAWSPubSub(path string) bool {
+func isAWSPubSub(path string, env EnvSource) bool {
 	return strings.HasPrefix(path, "awssns:///") ||
-		strings.HasPrefix(path, "awssqs://") ||
 		strings.HasPrefix(path, "arn:aws:sns") ||
-		strings.HasPrefix(path, "https://sqs.")
+		isSQSURL(path, env)
 }
 
-func normalizeAWSPubSub(srcUrl string) (string, error) {
+func isSQSURL(path string, env EnvSource) bool {
+	if strings.HasPrefix(path, "awssqs://") || strings.HasPrefix(path, "https://sqs.") || strings.HasPrefix(path, "arn:aws:sqs") {
+		return true
+	}
+	if u, err := url.Parse(path); err == nil && u.Scheme == "https" && sqsRegion(u.Hostname()) != "" {
+		// legacy endpoints: https://(region).queue.amazonaws.com/
+		return true
+	}
+	endpoint := awsEndpoint(env, "SQS")
+	return endpoint != "" && strings.HasPrefix(path, endpoint)
+}
+
+func normalizeAWSPubSub(srcUrl string, kind ResourceKind, env EnvSource) (string, error) {
+	input := srcUrl
 	if strings.HasPrefix(srcUrl, "arn:aws:sns") {
-		fragments := strings.Split(srcUrl, ":")
-		return "awssns:///" + srcUrl + "?region=" + fragments[3], nil
-	} else if strings.HasPrefix(srcUrl, "awssns:///") {
+		srcUrl = "awssns:///" + srcUrl
+	}
+	if strings.HasPrefix(srcUrl, "awssns:///") {
 		u, err := url.Parse(srcUrl)
 		if err != nil {
 			return "", err
 		}
-		if _, ok := u.Query()["region"]; !ok {
-			fragments := strings.Split(srcUrl, ":")
-			q := url.Values{}
-			q.Set("region", fragments[4])
-			u.RawQuery = q.Encode()
+		q := u.Query()
+		if _, ok := q["region"]; !ok {
+			fragments := strings.Split(u.Path, ":")
+			if len(fragments) < 6 || fragments[3] == "" {
+				return "", newNormalizeError(kind, "awssns", input, "region", ErrMalformedURL, "SNS topic ARN should be arn:aws:sns:(region):(account):(topic), but '%s'", srcUrl)
+			}
+			q.Set("region", fragments[3])
 		}
+		setAWSEndpoint(q, env, "SNS", false)
+		u.RawQuery = q.Encode()
 		return u.String(), nil
-	} else if strings.HasPrefix(srcUrl, "awssqs://https://") || strings.HasPrefix(srcUrl, "https://sqs.") {
-		if strings.HasPrefix(srcUrl, "awssqs://https://") {
-			srcUrl = srcUrl[len("awssqs://"):]
+	} else if isSQSURL(srcUrl, env) {
+		if strings.HasPrefix(srcUrl, "arn:aws:sqs") {
+			queueURL, err := sqsQueueURLFromARN(srcUrl, kind)
+			if err != nil {
+				return "", withInput(err, input)
+			}
+			srcUrl = queueURL
 		}
+		srcUrl = strings.TrimPrefix(srcUrl, "awssqs://")
 		u, err := url.Parse(srcUrl)
 		if err != nil {
 			return "", err
 		}
-		if _, ok := u.Query()["region"]; !ok {
-			fragments := strings.Split(u.Host, ".")
-			q := url.Values{}
-			q.Set("region", fragments[1])
-			u.RawQuery = q.Encode()
+		if err := validateSQSQueueName(path.Base(u.Path), kind); err != nil {
+			return "", withInput(err, input)
 		}
+		q := u.Query()
+		if _, ok := q["region"]; !ok {
+			if region := sqsRegion(u.Hostname()); region != "" {
+				q.Set("region", region)
+			} else if region, found := lookupEnv(env, "AWS_REGION"); found {
+				q.Set("region", region)
+			} else {
+				return "", newNormalizeError(kind, "awssqs", input, "region", ErrMissingRegion, "SQS URL '%s' doesn't have region and no AWS_REGION env var", srcUrl)
+			}
+		}
+		setAWSEndpoint(q, env, "SQS", false)
+		u.RawQuery = q.Encode()
 		return "awssqs://" + u.String(), nil
 	}
 	return srcUrl, nil
 }
 
-func normalizeGCPPubSub(p string) (string, error) {
+// sqsRegion returns region from SQS endpoint host name.
+//
+// It supports sqs.(region).amazonaws.com, legacy (region).queue.amazonaws.com and queue.amazonaws.com (us-east-1).
+// It returns empty string for other hosts.
+func sqsRegion(host string) string {
+	fragments := strings.Split(host, ".")
+	switch {
+	case len(fragments) == 4 && fragments[0] == "sqs" && fragments[2] == "amazonaws":
+		return fragments[1]
+	case len(fragments) == 4 && fragments[1] == "queue" && fragments[2] == "amazonaws":
+		return fragments[0]
+	case host == "queue.amazonaws.com":
+		return "us-east-1"
+	}
+	return ""
+}
+
+// sqsQueueURLFromARN converts arn:aws:sqs:(region):(account):(queue) into queue URL.
+func sqsQueueURLFromARN(arn string, kind ResourceKind) (string, error) {
+	fragments := strings.Split(arn, ":")
+	if len(fragments) != 6 || fragments[3] == "" || fragments[4] == "" || fragments[5] == "" {
+		return "", newNormalizeError(kind, "awssqs", arn, "queue", ErrMalformedURL, "SQS queue ARN should be arn:aws:sqs:(region):(account):(queue), but '%s'", arn)
+	}
+	return "https://sqs." + fragments[3] + ".amazonaws.com/" + fragments[4] + "/" + fragments[5] + "?region=" + fragments[3], nil
+}
+
+// sqsQueueNamePattern is a naming rule of SQS queue. FIFO queue name has ".fifo" suffix.
+var sqsQueueNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,80}$`)
+
+func validateSQSQueueName(name string, kind ResourceKind) error {
+	base := strings.TrimSuffix(name, ".fifo")
+	if !sqsQueueNamePattern.MatchString(base) || len(name) > 80 {
+		return newNormalizeError(kind, "awssqs", name, "queue", ErrInvalidName, "SQS queue name '%s' should have 1-80 alphanumeric characters, hyphens or underscores (and .fifo suffix for FIFO queue)", name)
+	}
+	return nil
+}
+
+func isAzureServiceBus(srcUrl string) bool {
+	return strings.HasPrefix(srcUrl, "azuresb://") || strings.HasPrefix(srcUrl, "Endpoint=sb://")
+}
+
+// normalizeAzureServiceBus normalizes azuresb URL or converts connection string into azuresb URL.
+//
+// Topic URL is azuresb://(topic). Subscription URL is azuresb://(topic)?subscription=(subscription).
+func normalizeAzureServiceBus(srcUrl string, subscription bool, env EnvSource) (string, error) {
+	kind := KindTopic
+	if subscription {
+		kind = KindSubscription
+	}
+	var u *url.URL
+	if strings.HasPrefix(srcUrl, "Endpoint=") {
+		entityPath := ""
+		for _, element := range strings.Split(srcUrl, ";") {
+			if strings.HasPrefix(element, "EntityPath=") {
+				entityPath = element[len("EntityPath="):]
+			}
+		}
+		// EntityPath is (topic) or (topic)/Subscriptions/(subscription)
+		fragments := strings.Split(entityPath, "/")
+		if entityPath == "" || (len(fragments) != 1 && (len(fragments) != 3 || !strings.EqualFold(fragments[1], "subscriptions"))) {
+			return "", newNormalizeError(kind, "", redactConnectionString(srcUrl), "EntityPath", ErrMalformedURL, "Service Bus connection string should have EntityPath=(topic) or EntityPath=(topic)/Subscriptions/(subscription)")
+		}
+		u = &url.URL{Scheme: "azuresb", Host: fragments[0]}
+		if len(fragments) == 3 {
+			u.RawQuery = url.Values{"subscription": []string{fragments[2]}}.Encode()
+		}
+	} else {
+		var err error
+		u, err = url.Parse(srcUrl)
+		if err != nil {
+			return "", err
+		}
+	}
+	if u.Host == "" || strings.Trim(u.Path, "/") != "" {
+		return "", newNormalizeError(kind, "azuresb", u.String(), "topic", ErrMalformedURL, "azuresb url should be azuresb://(topic), but '%s'", u.String())
+	}
+	if subscription && u.Query().Get("subscription") == "" {
+		return "", newNormalizeError(kind, "azuresb", u.String(), "subscription", ErrMalformedURL, "azuresb subscription url should be azuresb://(topic)?subscription=(subscription), but '%s'", u.String())
+	}
+	if connection, ok := lookupEnv(env, "SERVICEBUS_CONNECTION_STRING"); !ok || connection == "" {
+		return "", newNormalizeError(kind, "azuresb", u.String(), "SERVICEBUS_CONNECTION_STRING", ErrMissingEnv, "azuresb url '%s' requires SERVICEBUS_CONNECTION_STRING env var", u.String())
+	}
+	return u.String(), nil
+}
+
+// redactConnectionString hides SharedAccessKey of Service Bus connection string for errors.
+func redactConnectionString(connection string) string {
+	elements := strings.Split(connection, ";")
+	for i, element := range elements {
+		if strings.HasPrefix(element, "SharedAccessKey=") {
+			elements[i] = "SharedAccessKey=xxxxx"
+		}
+	}
+	return strings.Join(elements, ";")
+}
+
+func normalizeGCPPubSub(p string, env EnvSource) (string, error) {
+	return normalizeGCPPubSubResource(p, "topics", env)
+}
+
+// gcpPubSubIDPattern is a naming rule of topic and subscription IDs of Cloud Pub/Sub.
+var gcpPubSubIDPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9\-._~%+]{2,254}$`)
+
+// normalizeGCPPubSubResource normalizes topic (kind="topics") or subscription (kind="subscriptions") URL.
+func normalizeGCPPubSubResource(p, kind string, env EnvSource) (string, error) {
+	input := p
+	if strings.HasPrefix(p, "projects/") {
+		p = "gcppubsub://" + p
+	}
+	name := strings.TrimSuffix(kind, "s")
+	resourceKind := KindTopic
+	if kind == "subscriptions" {
+		resourceKind = KindSubscription
+	}
 	u, err := url.Parse(p)
 	if err != nil {
 		return "", err
 	}
+	if id := strings.TrimPrefix(u.Host+u.Path, "/"); id != "" && u.Host != "projects" && !strings.Contains(id, "/") {
+		// only topic (subscription) name: gcppubsub://mytopic
+		project, ok := gcpProject(env)
+		if !ok {
+			return "", newNormalizeError(resourceKind, "gcppubsub", input, "project", ErrMissingProject, "gcppubsub url doesn't have project name and no GOOGLE_CLOUD_PROJECT env var: %s", p)
+		}
+		u.Host = project
+		u.Path = "/" + id
+	}
 	fragments := strings.Split(u.Path, "/")
 	switch u.Host {
 	case "":
-		return "", errors.New("gcppubsub url should have project and topic names")
+		return "", newNormalizeError(resourceKind, "gcppubsub", input, "project", ErrMalformedURL, "gcppubsub url should have project and %s names", name)
 	case "projects":
-		if len(fragments) != 4 {
-			return "", errors.New("gcppubsub url should have project and topic names")
+		if len(fragments) != 4 || fragments[2] != kind {
+			return "", newNormalizeError(resourceKind, "gcppubsub", input, "path", ErrMalformedURL, "gcppubsub url should be gcppubsub://projects/(project)/%s/(%s), but '%s'", kind, name, p)
 		}
 	default:
 		if len(fragments) != 2 {
-			return "", errors.New("gcppubsub url should have project and topic names")
+			return "", newNormalizeError(resourceKind, "gcppubsub", input, "path", ErrMalformedURL, "gcppubsub url should have project and %s names", name)
 		}
-		u.Path = path.Join("/", u.Host, "topics", fragments[1])
+		u.Path = path.Join("/", u.Host, kind, fragments[1])
 		u.Host = "projects"
+		fragments = strings.Split(u.Path, "/")
 		p = u.String()
 	}
+	id := fragments[3]
+	if !gcpPubSubIDPattern.MatchString(id) || strings.HasPrefix(id, "goog") {
+		return "", newNormalizeError(resourceKind, "gcppubsub", input, name, ErrInvalidName, "%s ID '%s' should start with a letter, have 3-255 letters, numbers or -._~%%+ and not start with 'goog'", name, id)
+	}
 	return p, nil
 }
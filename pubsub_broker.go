@@ -0,0 +1,100 @@
+package gocloudurls
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// brokerServerEnvs are environment variables that gocloud.dev reads to connect to the servers.
+var brokerServerEnvs = map[string]string{
+	"kafka":  "KAFKA_BROKERS",
+	"nats":   "NATS_SERVER_URL",
+	"rabbit": "RABBIT_SERVER_URL",
+}
+
+func isBrokerPubSub(srcUrl string) bool {
+	for scheme := range brokerServerEnvs {
+		if strings.HasPrefix(srcUrl, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeBrokerPubSub validates URLs of Kafka, NATS and RabbitMQ.
+//
+// Server addresses are not in URL. gocloud.dev reads them from environment variables, so this function
+// checks that they exist.
+func normalizeBrokerPubSub(srcUrl string, subscription bool, env EnvSource) (string, error) {
+	u, err := url.Parse(srcUrl)
+	if err != nil {
+		return "", err
+	}
+	kind := KindTopic
+	if subscription {
+		kind = KindSubscription
+	}
+	envName := brokerServerEnvs[u.Scheme]
+	if server, ok := lookupEnv(env, envName); !ok || server == "" {
+		return "", newNormalizeError(kind, u.Scheme, srcUrl, envName, ErrMissingEnv, "%s url '%s' requires %s env var", u.Scheme, srcUrl, envName)
+	}
+	switch u.Scheme {
+	case "kafka":
+		return normalizeKafka(u, subscription)
+	case "nats":
+		if u.Host == "" {
+			return "", newNormalizeError(kind, "nats", srcUrl, "subject", ErrMalformedURL, "nats url should be nats://(subject), but '%s'", srcUrl)
+		}
+	case "rabbit":
+		if u.Host == "" {
+			if subscription {
+				return "", newNormalizeError(kind, "rabbit", srcUrl, "queue", ErrMalformedURL, "rabbit url should be rabbit://(queue), but '%s'", srcUrl)
+			}
+			return "", newNormalizeError(kind, "rabbit", srcUrl, "exchange", ErrMalformedURL, "rabbit url should be rabbit://(exchange), but '%s'", srcUrl)
+		}
+	}
+	return u.String(), nil
+}
+
+// kafkaTopicPattern is a naming rule of Kafka topics.
+var kafkaTopicPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,249}$`)
+
+// normalizeKafka normalizes Kafka URL.
+//
+// Topic URL is kafka://(topic). Subscription URL is kafka://(group)?topic=(topic) and
+// kafka://(topic)?group=(group) is also accepted.
+func normalizeKafka(u *url.URL, subscription bool) (string, error) {
+	q := u.Query()
+	if !subscription {
+		if u.Host == "" {
+			return "", newNormalizeError(KindTopic, "kafka", u.String(), "topic", ErrMalformedURL, "kafka url should be kafka://(topic), but '%s'", u.String())
+		}
+		if !kafkaTopicPattern.MatchString(u.Host) {
+			return "", newNormalizeError(KindTopic, "kafka", u.String(), "topic", ErrInvalidName, "kafka topic name '%s' should have 1-249 alphanumeric characters, '.', '_' or '-'", u.Host)
+		}
+		return u.String(), nil
+	}
+	var group, topic string
+	if g := q.Get("group"); g != "" {
+		group = g
+		topic = u.Host
+		q.Del("group")
+	} else {
+		group = u.Host
+		topic = q.Get("topic")
+	}
+	if topic == "" {
+		return "", newNormalizeError(KindSubscription, "kafka", u.String(), "topic", ErrMalformedURL, "kafka subscription url should have topic: kafka://(group)?topic=(topic), but '%s'", u.String())
+	}
+	if group == "" {
+		return "", newNormalizeError(KindSubscription, "kafka", u.String(), "group", ErrMalformedURL, "kafka subscription url should have consumer group: kafka://(group)?topic=(topic), but '%s'", u.String())
+	}
+	if !kafkaTopicPattern.MatchString(topic) {
+		return "", newNormalizeError(KindSubscription, "kafka", u.String(), "topic", ErrInvalidName, "kafka topic name '%s' should have 1-249 alphanumeric characters, '.', '_' or '-'", topic)
+	}
+	q.Set("topic", topic)
+	u.Host = group
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -1,6 +1,7 @@
 package gocloudurls
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -43,14 +44,82 @@ func TestNormalizeAWSPubSub(t *testing.T) {
 			hasError: false,
 			expected: "awssqs://https://sqs.us-east-2.amazonaws.com/123456789012/myqueue?region=us-east-2",
 		},
+		{
+			name:     "SQS - LocalStack",
+			src:      "http://localhost:4566/000000000000/myqueue",
+			hasError: false,
+			environs: []string{"AWS_ENDPOINT_URL=http://localhost:4566", "AWS_REGION=us-east-1"},
+			expected: "awssqs://http://localhost:4566/000000000000/myqueue?disableSSL=true&endpoint=http%3A%2F%2Flocalhost%3A4566&region=us-east-1",
+		},
+		{
+			name:     "SQS - LocalStack with awssqs scheme",
+			src:      "awssqs://http://localhost:4566/000000000000/myqueue",
+			hasError: false,
+			environs: []string{"AWS_ENDPOINT_URL_SQS=http://localhost:4566", "AWS_REGION=us-east-1"},
+			expected: "awssqs://http://localhost:4566/000000000000/myqueue?disableSSL=true&endpoint=http%3A%2F%2Flocalhost%3A4566&region=us-east-1",
+		},
+		{
+			name:     "SQS - LocalStack without region",
+			src:      "http://localhost:4566/000000000000/myqueue",
+			hasError: true,
+			environs: []string{"AWS_ENDPOINT_URL=http://localhost:4566"},
+		},
+		{
+			name:     "SQS - production URL with custom endpoint",
+			src:      "https://sqs.us-east-2.amazonaws.com/123456789012/myqueue",
+			hasError: false,
+			environs: []string{"AWS_ENDPOINT_URL_SQS=http://localhost:4566"},
+			expected: "awssqs://https://sqs.us-east-2.amazonaws.com/123456789012/myqueue?disableSSL=true&endpoint=http%3A%2F%2Flocalhost%3A4566&region=us-east-2",
+		},
+		{
+			name:     "SQS - ARN",
+			src:      "arn:aws:sqs:us-east-2:123456789012:myqueue",
+			hasError: false,
+			expected: "awssqs://https://sqs.us-east-2.amazonaws.com/123456789012/myqueue?region=us-east-2",
+		},
+		{
+			name:     "SQS - invalid ARN",
+			src:      "arn:aws:sqs:us-east-2:123456789012",
+			hasError: true,
+		},
+		{
+			name:     "SQS - legacy endpoint",
+			src:      "https://us-east-2.queue.amazonaws.com/123456789012/myqueue",
+			hasError: false,
+			expected: "awssqs://https://us-east-2.queue.amazonaws.com/123456789012/myqueue?region=us-east-2",
+		},
+		{
+			name:     "SQS - legacy us-east-1 endpoint",
+			src:      "https://queue.amazonaws.com/123456789012/myqueue",
+			hasError: false,
+			expected: "awssqs://https://queue.amazonaws.com/123456789012/myqueue?region=us-east-1",
+		},
+		{
+			name:     "SQS - FIFO queue",
+			src:      "arn:aws:sqs:us-east-2:123456789012:myqueue.fifo",
+			hasError: false,
+			expected: "awssqs://https://sqs.us-east-2.amazonaws.com/123456789012/myqueue.fifo?region=us-east-2",
+		},
+		{
+			name:     "SQS - invalid queue name",
+			src:      "https://sqs.us-east-2.amazonaws.com/123456789012/my.queue",
+			hasError: true,
+		},
+		{
+			name:     "SNS - ARN with custom endpoint",
+			src:      "arn:aws:sns:us-east-2:123456789012:mytopic",
+			hasError: false,
+			environs: []string{"AWS_ENDPOINT_URL_SNS=https://sns.example.com", "AWS_ENDPOINT_URL_SQS=http://localhost:4566"},
+			expected: "awssns:///arn:aws:sns:us-east-2:123456789012:mytopic?endpoint=https%3A%2F%2Fsns.example.com&region=us-east-2",
+		},
 	}
 	for _, testcase := range testcases {
 		t.Run(testcase.name, func(t *testing.T) {
-			result, err := normalizeAWSPubSub(testcase.src)
+			assert.True(t, isAWSPubSub(testcase.src, EnvList(testcase.environs)))
+			result, err := normalizeAWSPubSub(testcase.src, KindTopic, EnvList(testcase.environs))
 			if testcase.hasError {
 				assert.NotNil(t, err)
 			} else {
-				assert.True(t, isAWSPubSub(testcase.src))
 				assert.Nil(t, err)
 				assert.Equal(t, testcase.expected, result)
 			}
@@ -63,6 +132,7 @@ func TestFixCloudPubSub(t *testing.T) {
 		name     string
 		hasError bool
 		src      string
+		environ  map[string]string
 		expected string
 	}{
 		{
@@ -98,10 +168,207 @@ func TestFixCloudPubSub(t *testing.T) {
 			hasError: true,
 			expected: "gcppubsub://projects/myproject/topics/mytopic/test",
 		},
+		{
+			name:     "resource name without scheme",
+			hasError: false,
+			src:      "projects/myproject/topics/mytopic",
+			expected: "gcppubsub://projects/myproject/topics/mytopic",
+		},
+		{
+			name:     "invalid topic ID",
+			hasError: true,
+			src:      "gcppubsub://myproject/goog-topic",
+		},
+		{
+			name:     "project from env",
+			hasError: false,
+			src:      "gcppubsub://mytopic",
+			environ:  map[string]string{"GOOGLE_CLOUD_PROJECT": "myproject"},
+			expected: "gcppubsub://projects/myproject/topics/mytopic",
+		},
+		{
+			name:     "project from CLOUDSDK_CORE_PROJECT",
+			hasError: false,
+			src:      "gcppubsub:///mytopic",
+			environ:  map[string]string{"CLOUDSDK_CORE_PROJECT": "myproject"},
+			expected: "gcppubsub://projects/myproject/topics/mytopic",
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := normalizeGCPPubSub(testcase.src, EnvMap(testcase.environ))
+			if !testcase.hasError {
+				assert.Nil(t, err)
+				assert.Equal(t, testcase.expected, result)
+			} else {
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeSubscriptionURL(t *testing.T) {
+	testcases := []struct {
+		name     string
+		hasError bool
+		src      string
+		environ  map[string]string
+		expected string
+	}{
+		{
+			name:     "as is",
+			hasError: false,
+			src:      "gcppubsub://projects/myproject/subscriptions/mysub",
+			expected: "gcppubsub://projects/myproject/subscriptions/mysub",
+		},
+		{
+			name:     "short",
+			hasError: false,
+			src:      "gcppubsub://myproject/mysub",
+			expected: "gcppubsub://projects/myproject/subscriptions/mysub",
+		},
+		{
+			name:     "resource name without scheme",
+			hasError: false,
+			src:      "projects/myproject/subscriptions/mysub",
+			expected: "gcppubsub://projects/myproject/subscriptions/mysub",
+		},
+		{
+			name:     "project from env",
+			hasError: false,
+			src:      "gcppubsub://mysub",
+			environ:  map[string]string{"GOOGLE_CLOUD_PROJECT": "myproject"},
+			expected: "gcppubsub://projects/myproject/subscriptions/mysub",
+		},
+		{
+			name:     "error: topic resource name",
+			hasError: true,
+			src:      "projects/myproject/topics/mytopic",
+		},
+		{
+			name:     "error: too short ID",
+			hasError: true,
+			src:      "gcppubsub://myproject/ab",
+		},
+		{
+			name:     "error: starts with number",
+			hasError: true,
+			src:      "gcppubsub://myproject/1sub",
+		},
+		{
+			name:     "error: starts with goog",
+			hasError: true,
+			src:      "gcppubsub://myproject/google-sub",
+		},
+		{
+			name:     "error: invalid character",
+			hasError: true,
+			src:      "gcppubsub://myproject/my*sub",
+		},
+		{
+			name:     "SQS",
+			hasError: false,
+			src:      "https://sqs.us-east-2.amazonaws.com/123456789012/myqueue",
+			expected: "awssqs://https://sqs.us-east-2.amazonaws.com/123456789012/myqueue?region=us-east-2",
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := NormalizeSubscriptionURL(testcase.src, EnvMap(testcase.environ))
+			if !testcase.hasError {
+				assert.Nil(t, err)
+				assert.Equal(t, testcase.expected, result)
+			} else {
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeAzureServiceBus(t *testing.T) {
+	connection := "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=secret"
+	environ := map[string]string{"SERVICEBUS_CONNECTION_STRING": connection}
+	testcases := []struct {
+		name         string
+		hasError     bool
+		subscription bool
+		src          string
+		environ      map[string]string
+		expected     string
+	}{
+		{
+			name:     "topic",
+			src:      "azuresb://mytopic",
+			environ:  environ,
+			expected: "azuresb://mytopic",
+		},
+		{
+			name:         "subscription",
+			subscription: true,
+			src:          "azuresb://mytopic?subscription=mysub",
+			environ:      environ,
+			expected:     "azuresb://mytopic?subscription=mysub",
+		},
+		{
+			name:     "topic from connection string",
+			src:      connection + ";EntityPath=mytopic",
+			environ:  environ,
+			expected: "azuresb://mytopic",
+		},
+		{
+			name:         "subscription from connection string",
+			subscription: true,
+			src:          connection + ";EntityPath=mytopic/Subscriptions/mysub",
+			environ:      environ,
+			expected:     "azuresb://mytopic?subscription=mysub",
+		},
+		{
+			name:         "error: subscription without subscription name",
+			hasError:     true,
+			subscription: true,
+			src:          "azuresb://mytopic",
+			environ:      environ,
+		},
+		{
+			name:     "error: connection string with empty EntityPath",
+			hasError: true,
+			src:      connection + ";EntityPath=",
+			environ:  environ,
+		},
+		{
+			name:     "error: connection string without EntityPath",
+			hasError: true,
+			src:      connection,
+			environ:  environ,
+		},
+		{
+			name:         "error: connection string with malformed EntityPath",
+			hasError:     true,
+			subscription: true,
+			src:          connection + ";EntityPath=mytopic/mysub",
+			environ:      environ,
+		},
+		{
+			name:     "error: no SERVICEBUS_CONNECTION_STRING",
+			hasError: true,
+			src:      "azuresb://mytopic",
+		},
+		{
+			name:     "error: path after topic",
+			hasError: true,
+			src:      "azuresb://mytopic/extra",
+			environ:  environ,
+		},
 	}
 	for _, testcase := range testcases {
 		t.Run(testcase.name, func(t *testing.T) {
-			result, err := normalizeGCPPubSub(testcase.src)
+			var result string
+			var err error
+			if testcase.subscription {
+				result, err = NormalizeSubscriptionURL(testcase.src, EnvMap(testcase.environ))
+			} else {
+				result, err = NormalizePubSubURL(testcase.src, EnvMap(testcase.environ))
+			}
 			if !testcase.hasError {
 				assert.Nil(t, err)
 				assert.Equal(t, testcase.expected, result)
@@ -111,3 +378,12 @@ func TestFixCloudPubSub(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeAzureServiceBusRedactsKey(t *testing.T) {
+	_, err := NormalizePubSubURL("Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=Root;SharedAccessKey=secret;EntityPath=", EnvMap{})
+	var ne *NormalizeError
+	if assert.True(t, errors.As(err, &ne)) {
+		assert.Equal(t, "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=Root;SharedAccessKey=xxxxx;EntityPath=", ne.Input)
+		assert.NotContains(t, err.Error(), "secret")
+	}
+}
@@ -0,0 +1,124 @@
+package gocloudurls
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// Normalizer normalizes URL of custom scheme. opt.Environ is always set.
+type Normalizer func(srcUrl string, opt NormalizeOption) (string, error)
+
+// builtinSchemes are schemes that normalizers of this package handle before registered normalizers.
+// It has schemes of input forms (like "https" of S3 URL, "arn" of S3 ARN and "postgresql" alias) too.
+var builtinSchemes = map[ResourceKind][]string{
+	KindBlob:         {"file", "mem", "s3", "gs", "azblob", "arn", "https"},
+	KindDocStore:     {"mem", "firestore", "dynamodb", "mongo"},
+	KindTopic:        {"awssns", "awssqs", "gcppubsub", "azuresb", "kafka", "nats", "rabbit"},
+	KindSubscription: {"awssqs", "gcppubsub", "azuresb", "kafka", "nats", "rabbit"},
+	KindSecret:       {"awskms", "gcpkms", "azurekeyvault", "hashivault", "base64key", "https"},
+	KindRuntimeVar:   {"awsparamstore", "awssecretsmanager", "gcpruntimeconfig", "gcpsecretmanager", "file", "constant"},
+	KindSQL:          {"mysql", "postgres", "postgresql", "awsmysql", "awspostgres", "gcpmysql", "gcppostgres"},
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[ResourceKind]map[string]Normalizer{}
+)
+
+// Register adds normalizer for custom scheme (like in-house gocloud.dev driver).
+//
+// Normalize* functions of the kind call it for the URLs of the scheme. Registering the scheme
+// that is already registered or that this package supports returns error.
+//
+// Example:
+//
+//   gocloudurls.MustRegister(gocloudurls.KindBlob, "corpblob", func(srcUrl string, opt gocloudurls.NormalizeOption) (string, error) {
+//       return srcUrl + "?zone=tokyo", nil
+//   })
+//   gocloudurls.NormalizeBlobURL("corpblob://my-bucket", os.Environ())
+//   // "corpblob://my-bucket?zone=tokyo"
+func Register(kind ResourceKind, scheme string, normalizer Normalizer) error {
+	if _, ok := builtinSchemes[kind]; !ok {
+		return fmt.Errorf("Unknown resource kind: '%s'", kind)
+	}
+	if scheme == "" || normalizer == nil {
+		return fmt.Errorf("scheme and normalizer are required to register %s normalizer", kind)
+	}
+	for _, builtin := range builtinSchemes[kind] {
+		if builtin == scheme {
+			return fmt.Errorf("%s scheme '%s' is supported by gocloudurls", kind, scheme)
+		}
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := registry[kind][scheme]; ok {
+		return fmt.Errorf("%s scheme '%s' is already registered", kind, scheme)
+	}
+	if registry[kind] == nil {
+		registry[kind] = map[string]Normalizer{}
+	}
+	registry[kind][scheme] = normalizer
+	return nil
+}
+
+// MustRegister is similar to Register but raise panic if there is error
+func MustRegister(kind ResourceKind, scheme string, normalizer Normalizer) {
+	if err := Register(kind, scheme, normalizer); err != nil {
+		panic(err)
+	}
+}
+
+// Schemes returns sorted schemes of the kind. It contains both built-in and registered schemes.
+func Schemes(kind ResourceKind) []string {
+	schemes := append([]string{}, builtinSchemes[kind]...)
+	registryLock.RLock()
+	for scheme := range registry[kind] {
+		schemes = append(schemes, scheme)
+	}
+	registryLock.RUnlock()
+	sort.Strings(schemes)
+	return schemes
+}
+
+func lookupNormalizer(kind ResourceKind, scheme string) (Normalizer, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	normalizer, ok := registry[kind][scheme]
+	return normalizer, ok
+}
+
+// registeredKind returns kind of registered scheme for DetectKind. If the scheme is registered for multiple kinds,
+// it is not detected.
+func registeredKind(scheme string) (ResourceKind, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	var result ResourceKind
+	for kind, normalizers := range registry {
+		if _, ok := normalizers[scheme]; ok {
+			if result != "" {
+				return "", false
+			}
+			result = kind
+		}
+	}
+	return result, result != ""
+}
+
+// normalizeRegistered calls registered normalizer if the scheme of srcUrl is registered.
+func normalizeRegistered(kind ResourceKind, srcUrl string, opt NormalizeOption) (string, bool, error) {
+	u, err := url.Parse(srcUrl)
+	if err != nil || u.Scheme == "" {
+		return "", false, nil
+	}
+	normalizer, ok := lookupNormalizer(kind, u.Scheme)
+	if !ok {
+		return "", false, nil
+	}
+	if opt.Environ == nil {
+		opt.Environ = OSEnv()
+	}
+	result, err := normalizer(srcUrl, opt)
+	return result, true, err
+}
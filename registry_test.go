@@ -0,0 +1,92 @@
+package gocloudurls
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func unregister(kind ResourceKind, scheme string) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	delete(registry[kind], scheme)
+}
+
+func TestRegister(t *testing.T) {
+	corpBlob := func(srcUrl string, opt NormalizeOption) (string, error) {
+		zone, ok := opt.Environ.LookupEnv("CORP_ZONE")
+		if !ok {
+			return "", errors.New("no CORP_ZONE")
+		}
+		return srcUrl + "?zone=" + zone, nil
+	}
+	assert.Nil(t, Register(KindBlob, "corpblob", corpBlob))
+	defer unregister(KindBlob, "corpblob")
+
+	t.Run("normalize", func(t *testing.T) {
+		result, err := NormalizeBlobURL("corpblob://my-bucket", []string{"CORP_ZONE=tokyo"}, BlobOption{Prefix: "tenant-a"})
+		assert.Nil(t, err)
+		assert.Equal(t, "corpblob://my-bucket?prefix=tenant-a%2F&zone=tokyo", result)
+	})
+	t.Run("error from normalizer", func(t *testing.T) {
+		_, err := NormalizeBlobURL("corpblob://my-bucket", []string{})
+		assert.NotNil(t, err)
+	})
+	t.Run("other kind", func(t *testing.T) {
+		_, err := NormalizeDocStoreURL("corpblob://my-bucket")
+		assert.NotNil(t, err)
+	})
+	t.Run("detect kind", func(t *testing.T) {
+		kind, err := DetectKind("corpblob://my-bucket")
+		assert.Nil(t, err)
+		assert.Equal(t, KindBlob, kind)
+	})
+	t.Run("duplicated", func(t *testing.T) {
+		assert.NotNil(t, Register(KindBlob, "corpblob", corpBlob))
+	})
+	t.Run("built-in scheme", func(t *testing.T) {
+		assert.NotNil(t, Register(KindBlob, "s3", corpBlob))
+	})
+	t.Run("scheme of input form", func(t *testing.T) {
+		// they are handled before registered normalizers
+		assert.NotNil(t, Register(KindBlob, "https", corpBlob))
+		assert.NotNil(t, Register(KindBlob, "arn", corpBlob))
+		assert.NotNil(t, Register(KindSecret, "https", corpBlob))
+		assert.NotNil(t, Register(KindSQL, "postgresql", corpBlob))
+	})
+	t.Run("unknown kind", func(t *testing.T) {
+		assert.NotNil(t, Register(ResourceKind("queue"), "corpqueue", corpBlob))
+	})
+	t.Run("schemes", func(t *testing.T) {
+		assert.Equal(t, []string{"arn", "azblob", "corpblob", "file", "gs", "https", "mem", "s3"}, Schemes(KindBlob))
+	})
+}
+
+func TestRegisterEachKind(t *testing.T) {
+	normalizer := func(srcUrl string, opt NormalizeOption) (string, error) {
+		return srcUrl + "?normalized=true", nil
+	}
+	testcases := []struct {
+		kind     ResourceKind
+		scheme   string
+		src      string
+		expected string
+	}{
+		{kind: KindDocStore, scheme: "corpdoc", src: "corpdoc://tasks", expected: "corpdoc://tasks?normalized=true"},
+		{kind: KindTopic, scheme: "corpmq", src: "corpmq://events", expected: "corpmq://events?normalized=true"},
+		{kind: KindSubscription, scheme: "corpmq", src: "corpmq://events", expected: "corpmq://events?normalized=true"},
+		{kind: KindSecret, scheme: "corpkms", src: "corpkms://key", expected: "corpkms://key?normalized=true"},
+		{kind: KindRuntimeVar, scheme: "corpvar", src: "corpvar://config", expected: "corpvar://config?normalized=true"},
+		{kind: KindSQL, scheme: "corpsql", src: "corpsql://db", expected: "corpsql://db?normalized=true"},
+	}
+	for _, testcase := range testcases {
+		t.Run(string(testcase.kind), func(t *testing.T) {
+			assert.Nil(t, Register(testcase.kind, testcase.scheme, normalizer))
+			defer unregister(testcase.kind, testcase.scheme)
+			result, err := Normalize(testcase.kind, testcase.src, NormalizeOption{Environ: EnvMap{}})
+			assert.Nil(t, err)
+			assert.Equal(t, testcase.expected, result)
+		})
+	}
+}
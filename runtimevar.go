@@ -0,0 +1,242 @@
+package gocloudurls
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RuntimeVarOption is a option for NormalizeRuntimeVarURL
+//
+// Decoder is a decoder of variable value. "string", "json" ("jsonmap") and "bytes" are supported.
+// It overwrites decoder query parameter in source URL.
+//
+// Environ is a source of environment variables to fill default values. If it is nil, environment variables
+// of current process are used.
+type RuntimeVarOption struct {
+	Decoder string
+	Environ EnvSource
+}
+
+// runtimeVarDecoders maps decoder names into the names that gocloud.dev/runtimevar.DecoderByName accepts.
+var runtimeVarDecoders = map[string]string{
+	"string":  "string",
+	"bytes":   "bytes",
+	"json":    "jsonmap",
+	"jsonmap": "jsonmap",
+}
+
+// NormalizeRuntimeVarURL normalizes URL for gocloud.dev/runtimevar.
+//
+// Usually, config file specifies variable location and application code knows its format.
+// So it provides API to specify decoder by application code.
+//
+// When region is not specified for AWS Parameter Store or Secrets Manager, this function gets region information
+// from AWS_REGION environment variable.
+//
+// Examples:
+//
+//   * "arn:aws:ssm:us-east-2:123456789012:parameter/app/config"
+//     → "awsparamstore:///app/config?region=us-east-2"
+//   * "arn:aws:secretsmanager:us-east-2:123456789012:secret:prod/db"
+//     → "awssecretsmanager://prod/db?region=us-east-2"
+//   * "projects/p/configs/c/variables/v"
+//     → "gcpruntimeconfig://projects/p/configs/c/variables/v"
+//   * "projects/p/secrets/s/versions/latest"
+//     → "gcpsecretmanager://projects/p/secrets/s"
+//   * "/etc/app/config.json" (RuntimeVarOption{Decoder: "json"})
+//     → "file:///etc/app/config.json?decoder=jsonmap"
+func NormalizeRuntimeVarURL(srcUrl string, opt ...RuntimeVarOption) (string, error) {
+	var o RuntimeVarOption
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	env := envOrOS([]EnvSource{o.Environ})
+	var decoder string
+	if o.Decoder != "" {
+		var ok bool
+		decoder, ok = runtimeVarDecoders[o.Decoder]
+		if !ok {
+			return "", fmt.Errorf("Unknown decoder of runtimevar: '%s'", o.Decoder)
+		}
+	}
+	u, err := normalizeRuntimeVarLocation(srcUrl, env)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	if decoder != "" {
+		q.Set("decoder", decoder)
+	} else if d := q.Get("decoder"); d != "" {
+		decoder, ok := runtimeVarDecoders[d]
+		if !ok {
+			return "", fmt.Errorf("Unknown decoder of runtimevar: '%s'", d)
+		}
+		q.Set("decoder", decoder)
+	}
+	u.RawQuery = q.Encode()
+	if u.Scheme == "constant" {
+		// url.URL omits "//" if it doesn't have host
+		return "constant://?" + u.RawQuery, nil
+	}
+	return u.String(), nil
+}
+
+// MustNormalizeRuntimeVarURL is similar to NormalizeRuntimeVarURL but raise panic if there is error
+func MustNormalizeRuntimeVarURL(srcUrl string, opt ...RuntimeVarOption) string {
+	result, err := NormalizeRuntimeVarURL(srcUrl, opt...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func normalizeRuntimeVarLocation(srcUrl string, env EnvSource) (*url.URL, error) {
+	switch {
+	case strings.HasPrefix(srcUrl, "arn:aws:ssm:"):
+		return normalizeParamStoreARN(srcUrl, env)
+	case strings.HasPrefix(srcUrl, "arn:aws:secretsmanager:"):
+		return normalizeSecretsManagerARN(srcUrl, env)
+	case strings.HasPrefix(srcUrl, "projects/"):
+		if strings.Contains(srcUrl, "/secrets/") {
+			return normalizeGCPSecretManager("gcpsecretmanager://" + srcUrl)
+		}
+		return normalizeGCPRuntimeConfig("gcpruntimeconfig://" + srcUrl)
+	}
+	u, err := url.Parse(srcUrl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "":
+		abs, err := filepath.Abs(u.Path)
+		if err != nil {
+			return nil, err
+		}
+		return &url.URL{Scheme: "file", Path: filepath.ToSlash(abs), RawQuery: u.RawQuery}, nil
+	case "awsparamstore":
+		return normalizeAWSRuntimeVar(u, "", "SSM", env)
+	case "awssecretsmanager":
+		return normalizeAWSRuntimeVar(u, "", "SECRETS_MANAGER", env)
+	case "gcpruntimeconfig":
+		return normalizeGCPRuntimeConfig(srcUrl)
+	case "gcpsecretmanager":
+		return normalizeGCPSecretManager(srcUrl)
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("file url should be file:///(path), but '%s'", srcUrl)
+		}
+	case "constant":
+		if _, ok := u.Query()["val"]; !ok {
+			return nil, fmt.Errorf("constant url should be constant://?val=(value), but '%s'", srcUrl)
+		}
+	default:
+		if result, ok, err := normalizeRegistered(KindRuntimeVar, srcUrl, NormalizeOption{Environ: env}); ok {
+			if err != nil {
+				return nil, err
+			}
+			return url.Parse(result)
+		}
+	}
+	return u, nil
+}
+
+func normalizeAWSRuntimeVar(u *url.URL, region, service string, env EnvSource) (*url.URL, error) {
+	if strings.Trim(u.Host+u.Path, "/") == "" {
+		return nil, fmt.Errorf("%s url doesn't have variable name: '%s'", u.Scheme, u.String())
+	}
+	q := u.Query()
+	if region != "" {
+		q.Set("region", region)
+	} else if _, ok := q["region"]; !ok {
+		region, found := lookupEnv(env, "AWS_REGION")
+		if !found {
+			return nil, fmt.Errorf("%s url '%s' doesn't have region query and no AWS_REGION env var", u.Scheme, u.String())
+		}
+		q.Set("region", region)
+	}
+	setAWSEndpoint(q, env, service, false)
+	u.RawQuery = q.Encode()
+	return u, nil
+}
+
+// normalizeParamStoreARN converts arn:aws:ssm:(region):(account):parameter/(name) into awsparamstore URL.
+//
+// Hierarchical parameter name (/app/config) is shown as "parameter/app/config" in ARN.
+func normalizeParamStoreARN(arn string, env EnvSource) (*url.URL, error) {
+	fragments := strings.SplitN(arn, ":", 6)
+	if len(fragments) != 6 || fragments[3] == "" || !strings.HasPrefix(fragments[5], "parameter/") || fragments[5] == "parameter/" {
+		return nil, fmt.Errorf("Parameter Store ARN should be arn:aws:ssm:(region):(account):parameter/(name), but '%s'", arn)
+	}
+	name := strings.TrimPrefix(fragments[5], "parameter/")
+	u := &url.URL{Scheme: "awsparamstore", Host: name}
+	if strings.Contains(name, "/") {
+		u.Host = ""
+		u.Path = "/" + name
+	}
+	return normalizeAWSRuntimeVar(u, fragments[3], "SSM", env)
+}
+
+// secretsManagerSuffix is a random suffix that Secrets Manager adds to secret ARN.
+var secretsManagerSuffix = regexp.MustCompile(`-[A-Za-z0-9]{6}$`)
+
+// normalizeSecretsManagerARN converts partial ARN arn:aws:secretsmanager:(region):(account):secret:(name) into
+// awssecretsmanager URL.
+//
+// awssecretsmanager URL can't have ARN as secret ID, so the name is taken from ARN. If the name ends with
+// "-" and 6 characters, it can't be known whether it is the random suffix of complete ARN or a part of name
+// ("my-secret-AbCdEf" or "service-token1"), so it is an error.
+func normalizeSecretsManagerARN(arn string, env EnvSource) (*url.URL, error) {
+	fragments := strings.Split(arn, ":")
+	if len(fragments) != 7 || fragments[3] == "" || fragments[5] != "secret" || fragments[6] == "" {
+		return nil, fmt.Errorf("Secrets Manager ARN should be arn:aws:secretsmanager:(region):(account):secret:(name), but '%s'", arn)
+	}
+	name := fragments[6]
+	if secretsManagerSuffix.MatchString(name) {
+		return nil, fmt.Errorf("secret name of Secrets Manager ARN '%s' may have random suffix; use awssecretsmanager://(name)?region=%s instead", arn, fragments[3])
+	}
+	u := &url.URL{Scheme: "awssecretsmanager", Host: name}
+	if strings.Contains(name, "/") {
+		// secret name like "prod/db": host is "prod" and path is "/db"
+		index := strings.Index(name, "/")
+		u.Host = name[:index]
+		u.Path = name[index:]
+	}
+	return normalizeAWSRuntimeVar(u, fragments[3], "SECRETS_MANAGER", env)
+}
+
+func normalizeGCPRuntimeConfig(srcUrl string) (*url.URL, error) {
+	u, err := url.Parse(srcUrl)
+	if err != nil {
+		return nil, err
+	}
+	fragments := strings.Split(u.Path, "/")
+	if u.Host != "projects" || len(fragments) < 6 || fragments[2] != "configs" || fragments[4] != "variables" || fragments[5] == "" {
+		return nil, fmt.Errorf("gcpruntimeconfig url should be gcpruntimeconfig://projects/(project)/configs/(config)/variables/(variable), but '%s'", srcUrl)
+	}
+	return u, nil
+}
+
+// normalizeGCPSecretManager normalizes gcpsecretmanager URL.
+//
+// gocloud.dev reads the latest version, so "/versions/latest" is removed and other versions are errors.
+func normalizeGCPSecretManager(srcUrl string) (*url.URL, error) {
+	u, err := url.Parse(srcUrl)
+	if err != nil {
+		return nil, err
+	}
+	fragments := strings.Split(u.Path, "/")
+	if len(fragments) == 6 && fragments[4] == "versions" {
+		if fragments[5] != "latest" {
+			return nil, fmt.Errorf("gcpsecretmanager reads only the latest version of secret, but '%s'", srcUrl)
+		}
+		fragments = fragments[:4]
+		u.Path = strings.Join(fragments, "/")
+	}
+	if u.Host != "projects" || len(fragments) != 4 || fragments[1] == "" || fragments[2] != "secrets" || fragments[3] == "" {
+		return nil, fmt.Errorf("gcpsecretmanager url should be gcpsecretmanager://projects/(project)/secrets/(secret), but '%s'", srcUrl)
+	}
+	return u, nil
+}
@@ -0,0 +1,154 @@
+package gocloudurls
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeRuntimeVarURL(t *testing.T) {
+	wd, _ := os.Getwd()
+	testcases := []struct {
+		name     string
+		src      string
+		decoder  string
+		environ  map[string]string
+		hasError bool
+		expected string
+	}{
+		{
+			name:     "Parameter Store ARN",
+			src:      "arn:aws:ssm:us-east-2:123456789012:parameter/app/config",
+			expected: "awsparamstore:///app/config?region=us-east-2",
+		},
+		{
+			name:     "Parameter Store ARN without hierarchy",
+			src:      "arn:aws:ssm:us-east-2:123456789012:parameter/myvar",
+			decoder:  "string",
+			expected: "awsparamstore://myvar?decoder=string&region=us-east-2",
+		},
+		{
+			name:     "Parameter Store ARN error",
+			src:      "arn:aws:ssm:us-east-2:123456789012:document/mydoc",
+			hasError: true,
+		},
+		{
+			name:     "Parameter Store URL",
+			src:      "awsparamstore://myvar",
+			environ:  map[string]string{"AWS_REGION": "us-west-1"},
+			expected: "awsparamstore://myvar?region=us-west-1",
+		},
+		{
+			name:     "Parameter Store URL without region",
+			src:      "awsparamstore://myvar",
+			hasError: true,
+		},
+		{
+			name:     "Secrets Manager partial ARN",
+			src:      "arn:aws:secretsmanager:us-east-2:123456789012:secret:mysecret",
+			decoder:  "json",
+			expected: "awssecretsmanager://mysecret?decoder=jsonmap&region=us-east-2",
+		},
+		{
+			name:     "Secrets Manager partial ARN with hierarchy",
+			src:      "arn:aws:secretsmanager:us-east-2:123456789012:secret:prod/db",
+			expected: "awssecretsmanager://prod/db?region=us-east-2",
+		},
+		{
+			name:     "Secrets Manager complete ARN",
+			src:      "arn:aws:secretsmanager:us-east-2:123456789012:secret:my-secret-AbCdEf",
+			hasError: true,
+		},
+		{
+			name:     "Secrets Manager partial ARN like complete ARN",
+			src:      "arn:aws:secretsmanager:us-east-2:123456789012:secret:service-token1",
+			hasError: true,
+		},
+		{
+			name:     "Secrets Manager partial ARN with lowercase and digit name",
+			src:      "arn:aws:secretsmanager:us-east-2:123456789012:secret:my-key123",
+			hasError: true,
+		},
+		{
+			name:     "Secrets Manager URL",
+			src:      "awssecretsmanager://my-secret?decoder=string",
+			environ:  map[string]string{"AWS_REGION": "us-west-1"},
+			expected: "awssecretsmanager://my-secret?decoder=string&region=us-west-1",
+		},
+		{
+			name:     "Runtime Configurator",
+			src:      "projects/p/configs/c/variables/v",
+			expected: "gcpruntimeconfig://projects/p/configs/c/variables/v",
+		},
+		{
+			name:     "Runtime Configurator error",
+			src:      "gcpruntimeconfig://projects/p/configs/c",
+			hasError: true,
+		},
+		{
+			name:     "Secret Manager",
+			src:      "projects/p/secrets/s/versions/latest",
+			decoder:  "bytes",
+			expected: "gcpsecretmanager://projects/p/secrets/s?decoder=bytes",
+		},
+		{
+			name:     "Secret Manager without version",
+			src:      "gcpsecretmanager://projects/p/secrets/s",
+			expected: "gcpsecretmanager://projects/p/secrets/s",
+		},
+		{
+			name:     "Secret Manager with specific version",
+			src:      "projects/p/secrets/s/versions/3",
+			hasError: true,
+		},
+		{
+			name:     "absolute file path",
+			src:      "/etc/app/config.json",
+			decoder:  "json",
+			expected: "file:///etc/app/config.json?decoder=jsonmap",
+		},
+		{
+			name:     "relative file path",
+			src:      "config.json",
+			expected: "file://" + filepath.ToSlash(filepath.Join(wd, "config.json")),
+		},
+		{
+			name:     "constant",
+			src:      "constant://?val=hello",
+			decoder:  "string",
+			expected: "constant://?decoder=string&val=hello",
+		},
+		{
+			name:     "constant without value",
+			src:      "constant://",
+			hasError: true,
+		},
+		{
+			name:     "unknown decoder",
+			src:      "constant://?val=hello",
+			decoder:  "xml",
+			hasError: true,
+		},
+		{
+			name:     "unknown decoder in query",
+			src:      "constant://?val=hello&decoder=xml",
+			hasError: true,
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := NormalizeRuntimeVarURL(testcase.src, RuntimeVarOption{
+				Decoder: testcase.decoder,
+				Environ: EnvMap(testcase.environ),
+			})
+			if testcase.hasError {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, testcase.expected, result)
+			}
+		})
+	}
+}
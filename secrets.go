@@ -0,0 +1,145 @@
+package gocloudurls
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// NormalizeSecretsURL normalizes URL for gocloud.dev/secrets.
+//
+// When region is not specified for AWS KMS key ID or alias, this function gets region information from
+// AWS_REGION environment variable.
+//
+// "local" generates new random key for localsecrets. It is good for development.
+//
+// Examples:
+//
+//   * "arn:aws:kms:us-east-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+//     → "awskms:///arn:aws:kms:us-east-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab?region=us-east-2"
+//   * "alias/my-key"
+//     → "awskms://alias/my-key?region=us-west-1"
+//   * "projects/p/locations/l/keyRings/r/cryptoKeys/k"
+//     → "gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k"
+//   * "https://myvault.vault.azure.net/keys/mykey"
+//     → "azurekeyvault://myvault.vault.azure.net/keys/mykey"
+//   * "local"
+//     → "base64key://(random key)"
+//
+// env is a source of environment variables. If it is omitted, environment variables of current process are used.
+func NormalizeSecretsURL(srcUrl string, env ...EnvSource) (string, error) {
+	e := envOrOS(env)
+	switch {
+	case srcUrl == "local":
+		return generateBase64Key()
+	case strings.HasPrefix(srcUrl, "arn:aws:kms:"):
+		return normalizeAWSKMS("awskms:///"+srcUrl, e)
+	case strings.HasPrefix(srcUrl, "alias/"):
+		return normalizeAWSKMS("awskms://"+srcUrl, e)
+	case strings.HasPrefix(srcUrl, "projects/"):
+		return normalizeGCPKMS("gcpkms://" + srcUrl)
+	}
+	u, err := url.Parse(srcUrl)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "awskms":
+		return normalizeAWSKMS(srcUrl, e)
+	case "gcpkms":
+		return normalizeGCPKMS(srcUrl)
+	case "https", "azurekeyvault":
+		if strings.HasSuffix(u.Hostname(), ".vault.azure.net") {
+			return normalizeAzureKeyVault(u)
+		}
+	case "hashivault":
+		if u.Host == "" {
+			return "", fmt.Errorf("hashivault url should be hashivault://(key), but '%s'", srcUrl)
+		}
+		if server, ok := lookupEnv(e, "VAULT_SERVER_URL"); !ok || server == "" {
+			return "", fmt.Errorf("hashivault url '%s' requires VAULT_SERVER_URL env var", srcUrl)
+		}
+	case "base64key":
+		if u.Host == "" {
+			return generateBase64Key()
+		}
+		key, err := base64.URLEncoding.DecodeString(u.Host)
+		if err != nil || len(key) != 32 {
+			return "", fmt.Errorf("base64key url should have base64 encoded 32 bytes key, but '%s'", srcUrl)
+		}
+	default:
+		if result, ok, err := normalizeRegistered(KindSecret, srcUrl, NormalizeOption{Environ: e}); ok {
+			return result, err
+		}
+	}
+	return srcUrl, nil
+}
+
+// MustNormalizeSecretsURL is similar to NormalizeSecretsURL but raise panic if there is error
+func MustNormalizeSecretsURL(srcUrl string, env ...EnvSource) string {
+	result, err := NormalizeSecretsURL(srcUrl, env...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func normalizeAWSKMS(srcUrl string, env EnvSource) (string, error) {
+	u, err := url.Parse(srcUrl)
+	if err != nil {
+		return "", err
+	}
+	keyID := strings.TrimPrefix(u.Host+u.Path, "/")
+	if keyID == "" {
+		return "", fmt.Errorf("awskms url should have key ID, alias or ARN, but '%s'", srcUrl)
+	}
+	q := u.Query()
+	if _, ok := q["region"]; !ok {
+		if strings.HasPrefix(keyID, "arn:") {
+			fragments := strings.Split(keyID, ":")
+			if len(fragments) != 6 || fragments[3] == "" {
+				return "", fmt.Errorf("KMS key ARN should be arn:aws:kms:(region):(account):key/(key), but '%s'", keyID)
+			}
+			q.Set("region", fragments[3])
+		} else if region, found := lookupEnv(env, "AWS_REGION"); found {
+			q.Set("region", region)
+		} else {
+			return "", fmt.Errorf("awskms url '%s' doesn't have region query and no AWS_REGION env var", srcUrl)
+		}
+	}
+	setAWSEndpoint(q, env, "KMS", false)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func normalizeGCPKMS(srcUrl string) (string, error) {
+	u, err := url.Parse(srcUrl)
+	if err != nil {
+		return "", err
+	}
+	fragments := strings.Split(u.Path, "/")
+	if u.Host != "projects" || len(fragments) != 8 || fragments[2] != "locations" || fragments[4] != "keyRings" || fragments[6] != "cryptoKeys" {
+		return "", fmt.Errorf("gcpkms url should be gcpkms://projects/(project)/locations/(location)/keyRings/(keyring)/cryptoKeys/(key), but '%s'", srcUrl)
+	}
+	return u.String(), nil
+}
+
+// normalizeAzureKeyVault converts https://(vault).vault.azure.net/keys/(key)[/(version)] into azurekeyvault URL.
+func normalizeAzureKeyVault(u *url.URL) (string, error) {
+	fragments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if (len(fragments) != 2 && len(fragments) != 3) || fragments[0] != "keys" || fragments[1] == "" {
+		return "", fmt.Errorf("Key Vault URL should be https://(vault).vault.azure.net/keys/(key), but '%s'", u.String())
+	}
+	u.Scheme = "azurekeyvault"
+	return u.String(), nil
+}
+
+func generateBase64Key() (string, error) {
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return "", err
+	}
+	return "base64key://" + base64.URLEncoding.EncodeToString(key[:]), nil
+}
@@ -0,0 +1,127 @@
+package gocloudurls
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSecretsURL(t *testing.T) {
+	testcases := []struct {
+		name     string
+		src      string
+		environ  map[string]string
+		hasError bool
+		expected string
+	}{
+		{
+			name:     "AWS KMS ARN",
+			src:      "arn:aws:kms:us-east-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			expected: "awskms:///arn:aws:kms:us-east-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab?region=us-east-2",
+		},
+		{
+			name:     "AWS KMS alias",
+			src:      "alias/my-key",
+			environ:  map[string]string{"AWS_REGION": "us-west-1"},
+			expected: "awskms://alias/my-key?region=us-west-1",
+		},
+		{
+			name:     "AWS KMS alias without region",
+			src:      "alias/my-key",
+			hasError: true,
+		},
+		{
+			name:     "AWS KMS key ID",
+			src:      "awskms://1234abcd-12ab-34cd-56ef-1234567890ab",
+			environ:  map[string]string{"AWS_REGION": "us-west-1"},
+			expected: "awskms://1234abcd-12ab-34cd-56ef-1234567890ab?region=us-west-1",
+		},
+		{
+			name:     "AWS KMS with region",
+			src:      "awskms://alias/my-key?region=ap-northeast-1",
+			expected: "awskms://alias/my-key?region=ap-northeast-1",
+		},
+		{
+			name:     "AWS KMS with LocalStack",
+			src:      "alias/my-key",
+			environ:  map[string]string{"AWS_REGION": "us-east-1", "AWS_ENDPOINT_URL": "http://localhost:4566"},
+			expected: "awskms://alias/my-key?disableSSL=true&endpoint=http%3A%2F%2Flocalhost%3A4566&region=us-east-1",
+		},
+		{
+			name:     "GCP KMS resource name",
+			src:      "projects/p/locations/l/keyRings/r/cryptoKeys/k",
+			expected: "gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k",
+		},
+		{
+			name:     "GCP KMS URL",
+			src:      "gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k",
+			expected: "gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k",
+		},
+		{
+			name:     "GCP KMS invalid resource name",
+			src:      "projects/p/locations/l/keyRings/r",
+			hasError: true,
+		},
+		{
+			name:     "Azure Key Vault URL",
+			src:      "https://myvault.vault.azure.net/keys/mykey",
+			expected: "azurekeyvault://myvault.vault.azure.net/keys/mykey",
+		},
+		{
+			name:     "Azure Key Vault URL with version",
+			src:      "https://myvault.vault.azure.net/keys/mykey/0123456789abcdef",
+			expected: "azurekeyvault://myvault.vault.azure.net/keys/mykey/0123456789abcdef",
+		},
+		{
+			name:     "Azure Key Vault secret URL",
+			src:      "https://myvault.vault.azure.net/secrets/mysecret",
+			hasError: true,
+		},
+		{
+			name:     "HashiCorp Vault",
+			src:      "hashivault://mykey",
+			environ:  map[string]string{"VAULT_SERVER_URL": "http://127.0.0.1:8200"},
+			expected: "hashivault://mykey",
+		},
+		{
+			name:     "HashiCorp Vault without server",
+			src:      "hashivault://mykey",
+			hasError: true,
+		},
+		{
+			name:     "base64key",
+			src:      "base64key://smGbjm71Nxd1Ig5FS0wj9SlbzAIrnolCz9bQQ6uAhl4=",
+			expected: "base64key://smGbjm71Nxd1Ig5FS0wj9SlbzAIrnolCz9bQQ6uAhl4=",
+		},
+		{
+			name:     "base64key with short key",
+			src:      "base64key://c2hvcnQ=",
+			hasError: true,
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := NormalizeSecretsURL(testcase.src, EnvMap(testcase.environ))
+			if testcase.hasError {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, testcase.expected, result)
+			}
+		})
+	}
+}
+
+func TestNormalizeSecretsURLLocal(t *testing.T) {
+	result1, err := NormalizeSecretsURL("local", EnvMap{})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(result1, "base64key://"))
+	result2, err := NormalizeSecretsURL("local", EnvMap{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, result1, result2)
+	// generated key is valid
+	result3, err := NormalizeSecretsURL(result1, EnvMap{})
+	assert.Nil(t, err)
+	assert.Equal(t, result1, result3)
+}
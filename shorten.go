@@ -0,0 +1,157 @@
+package gocloudurls
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ShortenBlobURL converts normalized blob URL back into human friendly config form.
+//
+// It is a reverse function of NormalizeBlobURL. Query parameters that match environ
+// (AWS_REGION, AWS_ENDPOINT_URL and AZURE_STORAGE_ACCOUNT) are dropped, and directory style prefix
+// is moved back to the path. Normalizing the result again returns the original URL.
+//
+// Example:
+//
+//   * ``s3://my-bucket?region=us-west-1`` → ``s3://my-bucket`` (AWS_REGION=us-west-1)
+//   * ``gs://my-bucket?prefix=uploads%2F`` → ``gs://my-bucket/uploads``
+//   * ``file://folder`` → ``folder``
+//   * ``mem:`` → ``mem``
+func ShortenBlobURL(srcUrl string, environ []string) (string, error) {
+	u, err := url.Parse(srcUrl)
+	if err != nil {
+		return "", err
+	}
+	env := EnvList(environ)
+	q := u.Query()
+	switch u.Scheme {
+	case "s3":
+		if region, ok := lookupEnv(env, "AWS_REGION"); ok && q.Get("region") == region {
+			q.Del("region")
+		}
+		if endpoint := awsEndpoint(env, "S3"); endpoint != "" && q.Get("endpoint") == endpoint {
+			q.Del("endpoint")
+			q.Del("s3ForcePathStyle")
+			if strings.HasPrefix(endpoint, "http://") {
+				q.Del("disableSSL")
+			}
+		}
+	case "azblob":
+		if account, ok := lookupEnv(env, "AZURE_STORAGE_ACCOUNT"); ok && q.Get("storage_account") == account {
+			q.Del("storage_account")
+		}
+	case "mem":
+		if len(q) == 0 {
+			return "mem", nil
+		}
+		return srcUrl, nil
+	case "file":
+		if len(q) == 0 && u.Host == "" {
+			return u.Path, nil
+		}
+	}
+	switch u.Scheme {
+	case "s3", "gs", "azblob", "file":
+		if prefix := q.Get("prefix"); strings.HasSuffix(prefix, "/") && u.Path == "" {
+			u.Path = "/" + strings.TrimSuffix(prefix, "/")
+			q.Del("prefix")
+		}
+	}
+	u.RawQuery = q.Encode()
+	if u.Scheme == "file" && u.RawQuery == "" {
+		return u.Host + u.Path, nil
+	}
+	return u.String(), nil
+}
+
+// ShortenPubSubURL converts normalized PubSub URL back into human friendly config form.
+//
+// It is a reverse function of NormalizePubSubURL. Region that can be got from ARN or host name is dropped.
+//
+// Example:
+//
+//   * "awssns:///arn:aws:sns:us-east-2:123456789012:mytopic?region=us-east-2"
+//     → "arn:aws:sns:us-east-2:123456789012:mytopic"
+//   * "awssqs://https://sqs.us-east-2.amazonaws.com/123456789012/myqueue?region=us-east-2"
+//     → "https://sqs.us-east-2.amazonaws.com/123456789012/myqueue"
+//   * "gcppubsub://projects/myproject/topics/mytopic"
+//     → "gcppubsub://myproject/mytopic"
+func ShortenPubSubURL(srcUrl string) (string, error) {
+	loc, err := parseTopicLocation(srcUrl)
+	if err != nil {
+		return "", err
+	}
+	switch loc.Provider {
+	case "awssns":
+		if loc.Region == strings.Split(loc.ARN, ":")[3] {
+			if len(loc.Params) == 0 {
+				return loc.ARN, nil
+			}
+			return withQuery("awssns:///"+loc.ARN, loc.Params), nil
+		}
+	case "awssqs":
+		if u, err := url.Parse(loc.QueueURL); err == nil && u.Scheme == "https" && sqsRegion(u.Hostname()) == loc.Region {
+			return withQuery(loc.QueueURL, loc.Params), nil
+		}
+	case "gcppubsub":
+		return withQuery("gcppubsub://"+loc.Project+"/"+loc.Topic, loc.Params), nil
+	}
+	return loc.String(), nil
+}
+
+// ShortenDocStoreURL converts normalized DocStore URL back into human friendly config form.
+//
+// It is a reverse function of NormalizeDocStoreURL. Default values (key name ``_id`` and ``(default)`` database
+// of Firestore) are dropped. Shortened Firestore URL is used without Option.Collection (with Option.Collection,
+// "firestore://(project)/(database)" is expected).
+//
+// Example:
+//
+//   * "firestore://projects/my-project/databases/(default)/documents/addresses?name_field=_id"
+//     → "firestore://my-project/addresses"
+//   * "dynamodb://tasks?partition_key=_id"
+//     → "dynamodb://tasks"
+//   * "mongo://my-db/tasks?id_field=_id"
+//     → "mongo://my-db/tasks"
+//   * "mem://tasks/_id"
+//     → "mem://tasks"
+func ShortenDocStoreURL(srcUrl string) (string, error) {
+	u, err := url.Parse(srcUrl)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "firestore" && u.Scheme != "dynamodb" && u.Scheme != "mongo" && u.Scheme != "mem" {
+		return srcUrl, nil
+	}
+	loc, err := ParseDocStoreURL(srcUrl)
+	if err != nil {
+		return "", err
+	}
+	q := copyValues(loc.Params)
+	switch loc.Provider {
+	case "firestore":
+		if loc.PartitionKey != "_id" {
+			q.Set("name_field", loc.PartitionKey)
+		}
+		if loc.Database == "(default)" {
+			return withQuery("firestore://"+loc.Project+"/"+loc.Collection, q), nil
+		}
+		return withQuery("firestore://"+loc.Project+"/"+loc.Database+"/"+loc.Collection, q), nil
+	case "dynamodb":
+		if loc.PartitionKey != "_id" || loc.SortKey != "" {
+			return srcUrl, nil
+		}
+		return withQuery("dynamodb://"+loc.Collection, q), nil
+	case "mongo":
+		if loc.PartitionKey != "_id" {
+			q.Set("id_field", loc.PartitionKey)
+		}
+		return withQuery("mongo://"+loc.Database+"/"+loc.Collection, q), nil
+	case "mem":
+		if loc.PartitionKey != "_id" {
+			return srcUrl, nil
+		}
+		return withQuery("mem://"+loc.Collection, q), nil
+	}
+	return srcUrl, nil
+}
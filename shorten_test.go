@@ -0,0 +1,169 @@
+package gocloudurls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortenBlobURL(t *testing.T) {
+	testcases := []struct {
+		name     string
+		src      string
+		environs []string
+		expected string
+	}{
+		{
+			name:     "s3 with region in environ",
+			src:      "s3://my-bucket?region=us-west-1",
+			environs: []string{"AWS_REGION=us-west-1"},
+			expected: "s3://my-bucket",
+		},
+		{
+			name:     "s3 with other region",
+			src:      "s3://my-bucket?region=us-west-2",
+			environs: []string{"AWS_REGION=us-west-1"},
+			expected: "s3://my-bucket?region=us-west-2",
+		},
+		{
+			name:     "s3 with MinIO",
+			src:      "s3://my-bucket?disableSSL=true&endpoint=http%3A%2F%2Flocalhost%3A9000&prefix=tenant-a%2F&region=us-east-1&s3ForcePathStyle=true",
+			environs: []string{"AWS_REGION=us-east-1", "AWS_ENDPOINT_URL=http://localhost:9000"},
+			expected: "s3://my-bucket/tenant-a",
+		},
+		{
+			name:     "gs with prefix",
+			src:      "gs://my-bucket?prefix=uploads%2F",
+			expected: "gs://my-bucket/uploads",
+		},
+		{
+			name:     "azblob",
+			src:      "azblob://my-container?storage_account=myaccount",
+			environs: []string{"AZURE_STORAGE_ACCOUNT=myaccount"},
+			expected: "azblob://my-container",
+		},
+		{
+			name:     "file",
+			src:      "file://folder?prefix=sub%2F",
+			expected: "folder/sub",
+		},
+		{
+			name:     "absolute file",
+			src:      "file:///var/data",
+			expected: "/var/data",
+		},
+		{
+			name:     "mem",
+			src:      "mem:",
+			expected: "mem",
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := ShortenBlobURL(testcase.src, testcase.environs)
+			assert.Nil(t, err)
+			assert.Equal(t, testcase.expected, result)
+			normalized, err := normalizeBlobURL(result, EnvList(testcase.environs))
+			assert.Nil(t, err)
+			assert.Equal(t, testcase.src, normalized)
+		})
+	}
+}
+
+func TestShortenPubSubURL(t *testing.T) {
+	testcases := []struct {
+		name     string
+		src      string
+		expected string
+		hasError bool
+	}{
+		{
+			name:     "SNS",
+			src:      "awssns:///arn:aws:sns:us-east-2:123456789012:mytopic?region=us-east-2",
+			expected: "arn:aws:sns:us-east-2:123456789012:mytopic",
+		},
+		{
+			name:     "SQS",
+			src:      "awssqs://https://sqs.us-east-2.amazonaws.com/123456789012/myqueue?region=us-east-2",
+			expected: "https://sqs.us-east-2.amazonaws.com/123456789012/myqueue",
+		},
+		{
+			name:     "SQS legacy endpoint",
+			src:      "awssqs://https://us-east-2.queue.amazonaws.com/123456789012/myqueue?region=us-east-2",
+			expected: "https://us-east-2.queue.amazonaws.com/123456789012/myqueue",
+		},
+		{
+			name:     "Cloud Pub/Sub",
+			src:      "gcppubsub://projects/myproject/topics/mytopic",
+			expected: "gcppubsub://myproject/mytopic",
+		},
+		{
+			name:     "Cloud Pub/Sub subscription",
+			src:      "gcppubsub://projects/myproject/subscriptions/mysub",
+			hasError: true,
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := ShortenPubSubURL(testcase.src)
+			if testcase.hasError {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, testcase.expected, result)
+			normalized, err := NormalizePubSubURL(result)
+			assert.Nil(t, err)
+			assert.Equal(t, testcase.src, normalized)
+		})
+	}
+}
+
+func TestShortenDocStoreURL(t *testing.T) {
+	testcases := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name:     "Firestore",
+			src:      "firestore://projects/my-project/databases/(default)/documents/addresses?name_field=_id",
+			expected: "firestore://my-project/addresses",
+		},
+		{
+			name:     "Firestore with database and name field",
+			src:      "firestore://projects/my-project/databases/my-database/documents/addresses?name_field=name",
+			expected: "firestore://my-project/my-database/addresses?name_field=name",
+		},
+		{
+			name:     "DynamoDB",
+			src:      "dynamodb://tasks?partition_key=_id",
+			expected: "dynamodb://tasks",
+		},
+		{
+			name:     "DynamoDB with sort key",
+			src:      "dynamodb://tasks?partition_key=job_id&sort_key=_id",
+			expected: "dynamodb://tasks?partition_key=job_id&sort_key=_id",
+		},
+		{
+			name:     "MongoDB",
+			src:      "mongo://my-db/tasks?id_field=_id",
+			expected: "mongo://my-db/tasks",
+		},
+		{
+			name:     "Mem",
+			src:      "mem://tasks/_id",
+			expected: "mem://tasks",
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := ShortenDocStoreURL(testcase.src)
+			assert.Nil(t, err)
+			assert.Equal(t, testcase.expected, result)
+			normalized, err := NormalizeDocStoreURL(result)
+			assert.Nil(t, err)
+			assert.Equal(t, testcase.src, normalized)
+		})
+	}
+}
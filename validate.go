@@ -0,0 +1,414 @@
+package gocloudurls
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severity is a severity of Issue
+type Severity string
+
+// Severities of Issue
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem of URL that Validate finds.
+//
+// Field is a query parameter or environment variable name that has problem (empty if it is about whole URL).
+// Fix is a suggestion to solve it.
+type Issue struct {
+	Severity Severity
+	Field    string
+	Message  string
+	Fix      string
+}
+
+func (i Issue) String() string {
+	if i.Fix == "" {
+		return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s: %s (%s)", i.Severity, i.Message, i.Fix)
+}
+
+// HasError returns true if issues have SeverityError issue.
+func HasError(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredEnv is a environment variable that normalizers need. Validate checks it by normalizing
+// URL again with dummy value.
+type requiredEnv struct {
+	name    string
+	dummy   string
+	field   string
+	message string
+	fix     string
+}
+
+var requiredEnvs = []requiredEnv{
+	{"AWS_REGION", "us-east-1", "region", "region is not specified", "add region query parameter or set AWS_REGION env var"},
+	{"GOOGLE_CLOUD_PROJECT", "my-project", "project", "GCP project is not specified", "add project to URL or set GOOGLE_CLOUD_PROJECT env var"},
+	{"AZURE_STORAGE_ACCOUNT", "myaccount", "storage_account", "storage account is not specified", "add storage_account query parameter or set AZURE_STORAGE_ACCOUNT env var"},
+	{"MONGO_SERVER_URL", "mongodb://localhost/db", "MONGO_SERVER_URL", "MongoDB database is not specified", "add database to URL or set MONGO_SERVER_URL env var with database"},
+	{"SERVICEBUS_CONNECTION_STRING", "Endpoint=sb://ns.servicebus.windows.net/", "SERVICEBUS_CONNECTION_STRING", "Service Bus connection string is not set", "set SERVICEBUS_CONNECTION_STRING env var"},
+	{"KAFKA_BROKERS", "localhost:9092", "KAFKA_BROKERS", "Kafka brokers are not set", "set KAFKA_BROKERS env var"},
+	{"NATS_SERVER_URL", "nats://localhost:4222", "NATS_SERVER_URL", "NATS server is not set", "set NATS_SERVER_URL env var"},
+	{"RABBIT_SERVER_URL", "amqp://localhost:5672", "RABBIT_SERVER_URL", "RabbitMQ server is not set", "set RABBIT_SERVER_URL env var"},
+	{"VAULT_SERVER_URL", "http://localhost:8200", "VAULT_SERVER_URL", "Vault server is not set", "set VAULT_SERVER_URL env var"},
+}
+
+// knownQueryParams are query parameters that gocloud.dev drivers accept.
+var knownQueryParams = map[ResourceKind]map[string][]string{
+	KindBlob: {
+		"s3":     {"region", "endpoint", "disableSSL", "s3ForcePathStyle", "prefix", "profile", "awssdk", "dualstack", "fips", "accelerate", "anonymous"},
+		"gs":     {"prefix", "access_id", "private_key_path", "anonymous"},
+		"azblob": {"storage_account", "prefix", "domain", "protocol", "localemu", "cdn"},
+		"file":   {"prefix", "create_dir", "base_url", "secret_key_path", "metadata", "no_tmp_dir"},
+		"mem":    {"prefix"},
+	},
+	KindDocStore: {
+		"dynamodb":  {"partition_key", "sort_key", "allow_scans", "consistent_read", "region", "endpoint", "profile", "awssdk"},
+		"firestore": {"name_field", "revision_field"},
+		"mongo":     {"id_field", "revision_field"},
+		"mem":       {"filename", "revision_field"},
+	},
+	KindTopic: {
+		"awssns":    {"region", "endpoint", "disableSSL", "profile", "awssdk"},
+		"awssqs":    {"region", "endpoint", "disableSSL", "profile", "awssdk"},
+		"gcppubsub": {"max_send_batch_size"},
+		"azuresb":   {},
+		"kafka":     {},
+	},
+	KindSubscription: {
+		"awssqs":    {"region", "endpoint", "disableSSL", "profile", "awssdk", "raw", "waittime", "nacklazy"},
+		"gcppubsub": {"max_recv_batch_size"},
+		"azuresb":   {"subscription"},
+		"kafka":     {"topic", "offset"},
+	},
+	KindSecret: {
+		"awskms":        {"region", "endpoint", "disableSSL", "profile", "awssdk"},
+		"gcpkms":        {},
+		"azurekeyvault": {"algorithm"},
+		"base64key":     {},
+	},
+	KindSQL: {
+		"mysql":       mysqlQueryParams,
+		"awsmysql":    mysqlQueryParams,
+		"gcpmysql":    mysqlQueryParams,
+		"postgres":    postgresQueryParams,
+		"awspostgres": postgresQueryParams,
+		"gcppostgres": postgresQueryParams,
+	},
+	KindRuntimeVar: {
+		"awsparamstore":     {"region", "endpoint", "disableSSL", "profile", "awssdk", "decoder", "wait"},
+		"awssecretsmanager": {"region", "endpoint", "disableSSL", "profile", "awssdk", "decoder", "wait"},
+		"gcpruntimeconfig":  {"decoder", "wait"},
+		"gcpsecretmanager":  {"decoder", "wait"},
+		"file":              {"decoder", "wait"},
+		"constant":          {"val", "err", "decoder"},
+	},
+}
+
+// knownQueryParamPrefixes are prefixes of query parameters that have variable names.
+var knownQueryParamPrefixes = map[ResourceKind]map[string][]string{
+	KindSecret: {
+		"awskms": {"context_"},
+	},
+}
+
+// mysqlQueryParams are parameters of go-sql-driver/mysql DSN.
+var mysqlQueryParams = []string{
+	"allowAllFiles", "allowCleartextPasswords", "allowFallbackToPlaintext", "allowNativePasswords", "allowOldPasswords",
+	"charset", "checkConnLiveness", "collation", "clientFoundRows", "columnsWithAlias", "connectionAttributes",
+	"interpolateParams", "loc", "maxAllowedPacket", "multiStatements", "parseTime", "readTimeout", "rejectReadOnly",
+	"serverPubKey", "timeout", "timeTruncate", "tls", "writeTimeout",
+}
+
+// postgresQueryParams are parameters of lib/pq connection string.
+var postgresQueryParams = []string{
+	"sslmode", "sslcert", "sslkey", "sslrootcert", "sslinline", "connect_timeout", "application_name",
+	"fallback_application_name", "options", "search_path", "krbsrvname", "krbspn", "binary_parameters",
+	"disable_prepared_binary_result",
+}
+
+// keyQueryParams are query parameters of key fields of docstore.
+var keyQueryParams = map[string]string{
+	"dynamodb":  "partition_key",
+	"firestore": "name_field",
+	"mongo":     "id_field",
+}
+
+// Validate checks URL and returns all issues that it finds instead of the first error.
+//
+// It checks region, project and other values from environment variables, collection (presence and naming rule)
+// and key fields of docstore, and unknown query parameters of the scheme. If kind is empty, it is detected by DetectKind.
+//
+// Example:
+//
+//   issues := gocloudurls.Validate(gocloudurls.KindBlob, "s3://my-bucket?regoin=us-east-1")
+//   // error: region is not specified (add region query parameter or set AWS_REGION env var)
+//   // warning: unknown query parameter 'regoin' for s3 (did you mean 'region'?)
+func Validate(kind ResourceKind, srcUrl string, opt ...NormalizeOption) []Issue {
+	var o NormalizeOption
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	var issues []Issue
+	if kind == "" {
+		var err error
+		kind, err = DetectKind(srcUrl)
+		if err != nil {
+			return []Issue{{Severity: SeverityError, Message: err.Error(), Fix: "use URL of gocloud.dev or specify resource kind"}}
+		}
+	}
+	env := envOrOS([]EnvSource{o.Environ})
+	o.Environ = env
+	if o.DocStore.Environ == nil {
+		o.DocStore.Environ = env
+	}
+	if o.RuntimeVar.Environ == nil {
+		o.RuntimeVar.Environ = env
+	}
+	normalized, err := Normalize(kind, srcUrl, o)
+	if err != nil {
+		issues = append(issues, probeMissingValues(kind, srcUrl, o, err)...)
+		// query parameters are checked if source is URL
+		if u, parseErr := url.Parse(srcUrl); parseErr == nil {
+			issues = append(issues, checkQueryParams(kind, u)...)
+		}
+		return issues
+	}
+
+	u, parseErr := url.Parse(normalized)
+	if parseErr != nil {
+		return append(issues, Issue{Severity: SeverityError, Message: parseErr.Error()})
+	}
+	if kind == KindDocStore {
+		issues = append(issues, checkCollection(u)...)
+		if param, ok := keyQueryParams[u.Scheme]; ok && o.DocStore.KeyName == "" {
+			if raw, err := url.Parse(srcUrl); err == nil && raw.Query().Get(param) == "" && raw.Query().Get("sort_key") == "" {
+				issues = append(issues, Issue{
+					Severity: SeverityWarning,
+					Field:    param,
+					Message:  "key field is not specified. _id is used",
+					Fix:      fmt.Sprintf("add %s query parameter or set Option.KeyName", param),
+				})
+			}
+		}
+	}
+	issues = append(issues, checkQueryParams(kind, u)...)
+	return issues
+}
+
+// probeMissingValues finds the values that makes normalization succeed. If nothing is found,
+// it returns the error of normalization as is.
+//
+// At first, it normalizes URL again with dummy values of all missing environment variables (and collection).
+// If it succeeds, each value is removed one by one to find the values that are really required.
+// So independent problems (e.g. project and collection) are reported together.
+func probeMissingValues(kind ResourceKind, srcUrl string, o NormalizeOption, err error) []Issue {
+	var candidates []requiredEnv
+	for _, r := range requiredEnvs {
+		if _, ok := lookupEnv(o.Environ, r.name); !ok {
+			candidates = append(candidates, r)
+		}
+	}
+	probeCollection := kind == KindDocStore && o.DocStore.Collection == ""
+	probe := func(envs []requiredEnv, collection bool) bool {
+		p := o
+		values := map[string]string{}
+		for _, r := range envs {
+			values[r.name] = r.dummy
+		}
+		p.Environ = overlayEnv{base: o.Environ, values: values}
+		p.DocStore.Environ = p.Environ
+		p.RuntimeVar.Environ = p.Environ
+		if collection {
+			p.DocStore.Collection = "collection"
+		}
+		_, probeErr := Normalize(kind, srcUrl, p)
+		return probeErr == nil
+	}
+	envIssue := func(r requiredEnv) Issue {
+		return Issue{Severity: SeverityError, Field: r.field, Message: r.message, Fix: r.fix}
+	}
+	collectionIssue := Issue{
+		Severity: SeverityError,
+		Field:    "collection",
+		Message:  "collection is not specified",
+		Fix:      "add collection to URL or set Option.Collection",
+	}
+
+	var issues []Issue
+	if probe(candidates, probeCollection) {
+		for i, r := range candidates {
+			others := append(append([]requiredEnv{}, candidates[:i]...), candidates[i+1:]...)
+			if !probe(others, probeCollection) {
+				issues = append(issues, envIssue(r))
+			}
+		}
+		if probeCollection && !probe(candidates, false) {
+			issues = append(issues, collectionIssue)
+		}
+	}
+	if len(issues) == 0 {
+		// values may be alternatives of each other: report each value that is enough by itself
+		for _, r := range candidates {
+			if probe([]requiredEnv{r}, false) {
+				issues = append(issues, envIssue(r))
+			}
+		}
+		if probeCollection && probe(nil, true) {
+			issues = append(issues, collectionIssue)
+		}
+	}
+	if len(issues) == 0 {
+		issues = append(issues, Issue{Severity: SeverityError, Message: err.Error()})
+	}
+	return issues
+}
+
+var (
+	dynamoDBTableName   = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
+	firestoreReservedID = regexp.MustCompile(`^__.*__$`)
+)
+
+// checkCollection checks naming rule of collection of each service.
+func checkCollection(u *url.URL) []Issue {
+	var collection, message string
+	switch u.Scheme {
+	case "dynamodb":
+		collection = u.Host
+		if !dynamoDBTableName.MatchString(collection) {
+			message = "DynamoDB table name should be 3-255 characters of a-z, A-Z, 0-9, '_', '-' and '.'"
+		}
+	case "firestore":
+		fragments := strings.Split(u.Path, "/")
+		collection = fragments[len(fragments)-1]
+		switch {
+		case collection == "." || collection == "..":
+			message = "Firestore collection ID can't be '.' or '..'"
+		case firestoreReservedID.MatchString(collection):
+			message = "Firestore collection ID can't match __.*__"
+		case len(collection) > 1500:
+			message = "Firestore collection ID should be 1500 bytes or less"
+		}
+	case "mongo":
+		collection = strings.TrimPrefix(u.Path, "/")
+		switch {
+		case strings.ContainsAny(collection, "$\x00"):
+			message = "MongoDB collection name can't contain '$' and null character"
+		case strings.HasPrefix(collection, "system."):
+			message = "MongoDB collection name can't start with 'system.'"
+		}
+	}
+	if message == "" {
+		return nil
+	}
+	return []Issue{{
+		Severity: SeverityError,
+		Field:    "collection",
+		Message:  fmt.Sprintf("invalid collection name '%s'", collection),
+		Fix:      message,
+	}}
+}
+
+func checkQueryParams(kind ResourceKind, u *url.URL) []Issue {
+	known, ok := knownQueryParams[kind][u.Scheme]
+	if !ok {
+		return nil
+	}
+	prefixes := knownQueryParamPrefixes[kind][u.Scheme]
+	var names []string
+	for name := range u.Query() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var issues []Issue
+	for _, name := range names {
+		found := false
+		suggestion := ""
+		for _, k := range known {
+			if k == name {
+				found = true
+				break
+			}
+			if editDistance(k, name) <= 2 {
+				suggestion = k
+			}
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+		issue := Issue{
+			Severity: SeverityWarning,
+			Field:    name,
+			Message:  fmt.Sprintf("unknown query parameter '%s' for %s", name, u.Scheme),
+			Fix:      "remove it",
+		}
+		if suggestion != "" {
+			issue.Fix = fmt.Sprintf("did you mean '%s'?", suggestion)
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// overlayEnv is a EnvSource that adds variables to base.
+type overlayEnv struct {
+	base   EnvSource
+	values map[string]string
+}
+
+func (o overlayEnv) LookupEnv(key string) (string, bool) {
+	if value, ok := o.values[key]; ok {
+		return value, true
+	}
+	return lookupEnv(o.base, key)
+}
+
+// editDistance returns Levenshtein distance of two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min3(prev[j]+1, current[j-1]+1, prev[j-1]+cost)
+		}
+		prev = current
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}